@@ -1,6 +1,8 @@
 package superwatcher
 
 import (
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/soyart/gsl"
@@ -19,6 +21,62 @@ type Block struct {
 	Hash   common.Hash  `json:"hash"`
 	Header BlockHeader  `json:"-"`
 	Logs   []*types.Log `json:"logs"`
+
+	// Transactions is only populated if the poller fetched the full block,
+	// i.e. with PolicyExpensiveBlock.
+	Transactions []*Transaction `json:"transactions,omitempty"`
+
+	// Receipts maps transaction hashes of Logs to their receipts. It is only populated
+	// if the poller fetched receipts (Config.DoReceipts), and only for blocks in PollerResult.GoodBlocks.
+	Receipts map[common.Hash]*types.Receipt `json:"receipts,omitempty"`
+
+	// LogSubscriptions maps log indexes of Logs to IDs of subscriptions the logs matched.
+	// It is only populated if there are subscriptions registered with Controller.AddSubscription.
+	LogSubscriptions map[uint][]string `json:"logSubscriptions,omitempty"`
+
+	// Finality is the block's finality status when it was polled.
+	// It is only set if the poller checks finality (Config.DoFinality).
+	Finality Finality `json:"finality"`
+}
+
+// Finality (enum) is the finality status of a Block, based on the `safe` and `finalized` block tags.
+type Finality uint8
+
+const (
+	// FinalityUnknown is used when the poller does not check finality
+	FinalityUnknown Finality = iota
+	// FinalityLatest is for blocks newer than the `safe` block, which can still be reorged
+	FinalityLatest
+	// FinalitySafe is for blocks at or below the `safe` block, which are unlikely to be reorged
+	FinalitySafe
+	// FinalityFinalized is for blocks at or below the `finalized` block, which can never be reorged
+	FinalityFinalized
+)
+
+func (f Finality) String() string {
+	switch f {
+	case FinalityUnknown:
+		return "UNKNOWN"
+	case FinalityLatest:
+		return "LATEST"
+	case FinalitySafe:
+		return "SAFE"
+	case FinalityFinalized:
+		return "FINALIZED"
+	}
+
+	return fmt.Sprintf("UNKNOWN FINALITY %d", f)
+}
+
+// Transaction represents a transaction in a Block, as returned by
+// `eth_getBlockByNumber` with full transaction objects.
+// Hash and From are taken from the node's response, so users
+// do not have to compute or recover them from Tx themselves.
+type Transaction struct {
+	Hash  common.Hash        `json:"hash"`
+	From  common.Address     `json:"from"`
+	Index uint               `json:"transactionIndex"`
+	Tx    *types.Transaction `json:"tx"`
 }
 
 // String returns the block hash with 0x prepended in all lowercase string.
@@ -26,6 +84,11 @@ func (b *Block) String() string {
 	return gsl.StringerToLowerString(b.Hash)
 }
 
+// SubscriptionsOf returns IDs of subscriptions |log| in b matched
+func (b *Block) SubscriptionsOf(log *types.Log) []string {
+	return b.LogSubscriptions[log.Index]
+}
+
 // BlockHeader is implemented by `blockHeaderWrapper` and `*reorgsim.Block`.
 // It is used in place of *types.Header to make writing tests with reorgsim easier.
 // More methods may be added as our needs for data from the headers grow,
@@ -33,6 +96,7 @@ func (b *Block) String() string {
 type BlockHeader interface {
 	Number() uint64
 	Hash() common.Hash
+	ParentHash() common.Hash
 	Nonce() types.BlockNonce
 	Time() uint64
 	GasLimit() uint64
@@ -52,6 +116,10 @@ func (h BlockHeaderWrapper) Hash() common.Hash {
 	return h.Header.Hash()
 }
 
+func (h BlockHeaderWrapper) ParentHash() common.Hash {
+	return h.Header.ParentHash
+}
+
 func (h BlockHeaderWrapper) Nonce() types.BlockNonce {
 	return h.Header.Nonce
 }
@@ -1,32 +1,179 @@
 package superwatcher
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 // Config is superwatcher-wide configuration
 type Config struct {
 	// External dependencies
 	NodeURL string `mapstructure:"node_url" yaml:"node_url" json:"nodeURL"`
 
+	// NodeURLs are node endpoints with priorities for failover clients (see package pkg/failover).
+	// If empty, NodeURL is used as the only endpoint
+	NodeURLs []NodeEndpoint `mapstructure:"node_urls" yaml:"node_urls" json:"nodeURLs"`
+
 	// StartBlock is the shortest block height the emitter will consider as base, usually a contract's genesis block
 	StartBlock uint64 `mapstructure:"start_block" yaml:"start_block" json:"startBlock"`
 
 	// FilterRange is the forward range (number of new blocks) each call to emitter.poller.poll will perform
 	FilterRange uint64 `mapstructure:"filter_range" yaml:"filter_range" json:"filterRange"`
 
+	// AddressChunkSize is the max number of addresses in each FilterLogs query. Larger watch lists are split
+	// into chunks queried concurrently for the same block range. 0 means all addresses are sent in 1 query
+	AddressChunkSize uint64 `mapstructure:"address_chunk_size" yaml:"address_chunk_size" json:"addressChunkSize"`
+
 	// DoReorg specifies whether superwatcher superwatcher.EmitterPoller will process chain reorg for PollerResult
 	DoReorg bool `mapstructure:"do_reorg" yaml:"do_reorg" json:"doReorg"`
 
 	// DoHeader specifies whether superwatcher.EmitterPoller should fetch block headers too
 	DoHeader bool `mapstructure:"do_header" yaml:"do_header" json:"doHeader"`
 
+	// DoParentHash specifies whether superwatcher.EmitterPoller should check parent hashes of all blocks in range,
+	// so that chain reorgs are detected at the exact fork point, even on blocks without interesting logs
+	DoParentHash bool `mapstructure:"do_parent_hash" yaml:"do_parent_hash" json:"doParentHash"`
+
+	// DoReceipts specifies whether superwatcher.EmitterPoller should fetch transaction receipts
+	// for blocks with interesting logs in PollerResult.GoodBlocks
+	DoReceipts bool `mapstructure:"do_receipts" yaml:"do_receipts" json:"doReceipts"`
+
+	// DoFinality specifies whether superwatcher.EmitterPoller should get the `safe` and `finalized` blocks,
+	// to mark blocks with their finality, and stop re-verifying blocks that were already finalized
+	DoFinality bool `mapstructure:"do_finality" yaml:"do_finality" json:"doFinality"`
+
+	// DoVerifyBlockHash specifies whether superwatcher.EmitterPoller should re-fetch logs with EIP-234 block hash queries
+	// for blocks whose logs are inconsistent with their headers, instead of re-polling the whole range
+	DoVerifyBlockHash bool `mapstructure:"do_verify_block_hash" yaml:"do_verify_block_hash" json:"doVerifyBlockHash"`
+
+	// TrackerFile is the path to the file used to persist superwatcher.EmitterPoller's tracked blocks,
+	// so that blocks reorged while the service was down are reported after restarts. Empty means no persistence.
+	TrackerFile string `mapstructure:"tracker_file" yaml:"tracker_file" json:"trackerFile"`
+
+	// Confirmations is the number of blocks a block must be behind the chain head before the engine delivers it.
+	// Blocks in the unconfirmed window are still polled for chain reorg detection, but are not delivered.
+	Confirmations uint64 `mapstructure:"confirmations" yaml:"confirmations" json:"confirmations"`
+
+	// BackfillWorkers is the number of concurrent workers used to backfill blocks far behind the chain head.
+	// If 0, the emitter does not backfill, and polls all blocks FilterRange at a time with chain reorg detection.
+	// Backfill does not support address discovery, so it fails with ErrBadBackfill if the poller has a DiscoveryFunc
+	BackfillWorkers uint64 `mapstructure:"backfill_workers" yaml:"backfill_workers" json:"backfillWorkers"`
+
+	// BackfillChunkSize is the number of blocks polled by each backfill worker at a time. If 0, FilterRange is used
+	BackfillChunkSize uint64 `mapstructure:"backfill_chunk_size" yaml:"backfill_chunk_size" json:"backfillChunkSize"`
+
+	// BackfillSafetyMargin is the number of blocks behind the chain head (or the newest confirmed block) where backfill stops,
+	// and the emitter switches to normal polling with chain reorg detection
+	BackfillSafetyMargin uint64 `mapstructure:"backfill_safety_margin" yaml:"backfill_safety_margin" json:"backfillSafetyMargin"`
+
 	// MaxGoBackRetries is the maximum number of blocks the emitter will go back for. Once this is reached,
 	// the emitter exits on error ErrMaxRetriesReached
 	MaxGoBackRetries uint64 `mapstructure:"max_go_back_retries" yaml:"max_go_back_retries" json:"maxGoBackRetries"`
 
-	// LoopInterval is the number of seconds the emitter sleeps after each call to emitter.poller.poll
+	// LoopInterval is the number of seconds the emitter sleeps after each call to emitter.poller.poll.
+	// PollInterval takes precedence if set
 	LoopInterval uint64 `mapstructure:"loop_interval" yaml:"loop_interval" json:"loopInterval"`
 
+	// PollInterval is how long the emitter sleeps after each call to emitter.poller.poll, e.g. 500ms for fast chains.
+	// If 0, LoopInterval seconds is used. In JSON, it is either a duration string like "500ms", or nanoseconds
+	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval" json:"pollInterval"`
+
+	// AdaptiveInterval makes the emitter measure the chain's block time from block header timestamps,
+	// and sleep just long enough to expect the next block, or less if it is more than FilterRange blocks behind.
+	// Interval() is used until the block time is known, and caps the adaptive interval if not 0
+	AdaptiveInterval bool `mapstructure:"adaptive_interval" yaml:"adaptive_interval" json:"adaptiveInterval"`
+
+	// IntervalJitter randomizes each emitter sleep by up to ±IntervalJitter (0-1) of its value
+	IntervalJitter float64 `mapstructure:"interval_jitter" yaml:"interval_jitter" json:"intervalJitter"`
+
+	// CallTimeout is the number of seconds each emitter call to the node or the state data gateway
+	// (e.g. BlockNumber or GetLastRecordedBlock) may take before it is canceled. 0 means no timeout
+	CallTimeout uint64 `mapstructure:"call_timeout" yaml:"call_timeout" json:"callTimeout"`
+
+	// PollTimeout is the number of seconds each call to emitter.poller.poll (or backfill chunk), including retries,
+	// may take before it is canceled. 0 means no timeout
+	PollTimeout uint64 `mapstructure:"poll_timeout" yaml:"poll_timeout" json:"pollTimeout"`
+
+	// SubscribeNewHeads makes the emitter subscribe to new heads (`eth_subscribe("newHeads")`, requires a WebSocket NodeURL),
+	// and poll as soon as a new head arrives instead of sleeping for Interval(). If the subscription drops,
+	// the emitter falls back to sleeping for Interval() until it resubscribes
+	SubscribeNewHeads bool `mapstructure:"subscribe_new_heads" yaml:"subscribe_new_heads" json:"subscribeNewHeads"`
+
+	// Retry configures retries for transient RPC failures, and the emitter's circuit breaker. Nil means no retries,
+	// and every error is sent to ServiceEngine.HandleEmitterError
+	Retry *RetryPolicy `mapstructure:"retry" yaml:"retry" json:"retry"`
+
 	// LogLevel for debugger.Debugger, the higher the more verbose
 	LogLevel uint8 `mapstructure:"log_level" yaml:"log_level" json:"logLevel"`
 
 	// Policy is for configuring EmitterPoller behavior
 	Policy Policy `mapstructure:"policy" yaml:"policy" json:"policy"`
 }
+
+// NodeEndpoint is a node URL with its priority. Endpoints with lower Priority values are preferred.
+type NodeEndpoint struct {
+	URL      string `mapstructure:"url" yaml:"url" json:"url"`
+	Priority int    `mapstructure:"priority" yaml:"priority" json:"priority"`
+}
+
+// Endpoints returns conf.NodeURLs, or conf.NodeURL as the only endpoint if NodeURLs is empty
+func (conf *Config) Endpoints() []NodeEndpoint {
+	if len(conf.NodeURLs) != 0 {
+		return conf.NodeURLs
+	}
+
+	if conf.NodeURL == "" {
+		return nil
+	}
+
+	return []NodeEndpoint{{URL: conf.NodeURL}}
+}
+
+// Interval returns conf.PollInterval, or conf.LoopInterval seconds if PollInterval is 0
+func (conf *Config) Interval() time.Duration {
+	if conf.PollInterval != 0 {
+		return conf.PollInterval
+	}
+
+	return time.Second * time.Duration(conf.LoopInterval)
+}
+
+// UnmarshalJSON unmarshals |b| into conf, with PollInterval as either a duration string or nanoseconds
+func (conf *Config) UnmarshalJSON(b []byte) error {
+	type config Config
+	aux := struct {
+		*config
+		PollInterval json.RawMessage `json:"pollInterval"`
+	}{
+		config: (*config)(conf),
+	}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.PollInterval) == 0 || string(aux.PollInterval) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(aux.PollInterval, &s); err == nil {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.Wrapf(err, "invalid pollInterval %s", s)
+		}
+
+		conf.PollInterval = d
+		return nil
+	}
+
+	var ns int64
+	if err := json.Unmarshal(aux.PollInterval, &ns); err != nil {
+		return errors.Wrapf(err, "invalid pollInterval %s", aux.PollInterval)
+	}
+
+	conf.PollInterval = time.Duration(ns)
+	return nil
+}
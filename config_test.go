@@ -0,0 +1,47 @@
+package superwatcher
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestConfigUnmarshalJSON(t *testing.T) {
+	tests := map[string]time.Duration{
+		`{"filterRange": 10, "pollInterval": "500ms"}`:    500 * time.Millisecond,
+		`{"filterRange": 10, "pollInterval": 2000000000}`: 2 * time.Second,
+		`{"filterRange": 10, "pollInterval": null}`:       0,
+		`{"filterRange": 10}`:                             0,
+	}
+
+	for s, expected := range tests {
+		var conf Config
+		if err := json.Unmarshal([]byte(s), &conf); err != nil {
+			t.Fatalf("unexpected error for %s: %s", s, err.Error())
+		}
+
+		if conf.PollInterval != expected {
+			t.Errorf("unexpected PollInterval for %s: expecting %s, got %s", s, expected, conf.PollInterval)
+		}
+		if conf.FilterRange != 10 {
+			t.Errorf("unexpected FilterRange for %s: %d", s, conf.FilterRange)
+		}
+	}
+
+	var conf Config
+	if err := json.Unmarshal([]byte(`{"pollInterval": "500 blocks"}`), &conf); err == nil {
+		t.Error("expecting error for invalid pollInterval")
+	}
+
+	// Marshaled configs must round trip
+	b, err := json.Marshal(&Config{PollInterval: 250 * time.Millisecond})
+	if err != nil {
+		t.Fatal("unexpected marshal error", err.Error())
+	}
+	if err := json.Unmarshal(b, &conf); err != nil {
+		t.Fatal("unexpected unmarshal error", err.Error())
+	}
+	if conf.PollInterval != 250*time.Millisecond {
+		t.Errorf("unexpected round trip PollInterval %s", conf.PollInterval)
+	}
+}
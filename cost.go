@@ -0,0 +1,18 @@
+package superwatcher
+
+// Cost is the RPC usage of an EthClient, e.g. during a poll.
+type Cost struct {
+	Calls        map[string]uint64 // Number of calls by EthClient method, and of batch elements by JSON-RPC method
+	ComputeUnits uint64            // Total compute units (CU) of the calls, as billed by the node provider
+	// OverBudget is true if the client exceeded its CU budget. EmitterPoller degrades itself when it sees
+	// an OverBudget cost, by switching from PolicyExpensive to PolicyNormal and halving its filter range.
+	OverBudget bool
+}
+
+// CostReporter is implemented by EthClient that accounts for its RPC usage, e.g. clients from pkg/budget.
+// If EmitterPoller's client implements CostReporter, the poller takes the cost after each poll
+// and reports it in PollerResult.Cost.
+type CostReporter interface {
+	// TakeCost returns the cost accumulated since the last call to TakeCost, and resets it.
+	TakeCost() Cost
+}
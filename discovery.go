@@ -0,0 +1,11 @@
+package superwatcher
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DiscoveryFunc maps a log to new addresses and topics for EmitterPoller to watch, e.g. a factory contract's
+// event log to the addresses of the child contracts it created. It returns nil if |log| creates nothing.
+// A DiscoveryFunc is called for every log polled, so it should be cheap.
+type DiscoveryFunc func(log *types.Log) (addresses []common.Address, topics [][]common.Hash)
@@ -20,4 +20,15 @@ type Emitter interface {
 	Poller() EmitterPoller
 	// SetPoller overwrites emitter's Poller with a new one
 	SetPoller(EmitterPoller)
+	// Pause stops Emitter from polling after the current loop, until Resume is called
+	Pause()
+	// Resume resumes polling after Pause
+	Resume()
+	// SeekTo makes Emitter continue from the given block in its next loop. If the block was already emitted,
+	// blocks from it to the last recorded block are first emitted as ReorgedBlocks, so that ServiceEngine
+	// can undo them, and are then polled again as GoodBlocks. Seeking forward skips blocks in between,
+	// up to the block after the confirmed block.
+	SeekTo(uint64) error
+	// Rewind reprocesses the last n recorded blocks, like SeekTo(lastRecordedBlock + 1 - n)
+	Rewind(n uint64)
 }
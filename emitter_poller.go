@@ -10,10 +10,29 @@ import (
 type EmitterPoller interface {
 	// Poll polls event logs from fromBlock to toBlock, and process the logs into *PollerResult for Emitter
 	Poll(ctx context.Context, fromBlock, toBlock uint64) (*PollerResult, error)
-	// Policy gets current Policy
-	Policy() Policy
-	// SetPolicy sets new Policy (NOTE: changing Policy mid-run not tested)
-	SetPolicy(Policy) error
+	// Backfill polls event logs from fromBlock to toBlock without chain reorg detection, for blocks deep in history.
+	// Unlike Poll, Backfill does not change EmitterPoller states, and it is safe to call it concurrently.
+	Backfill(ctx context.Context, fromBlock, toBlock uint64) (*PollerResult, error)
+	// Rewind stops tracking blocks from fromBlock onward, and returns blocks from fromBlock to toBlock
+	// as ReorgedBlocks of a PollerResult with Rewind set, so that they can be undone and polled again.
+	Rewind(ctx context.Context, fromBlock, toBlock uint64) (*PollerResult, error)
+	// FilterRangeLimit returns the maximum FilterLogs range (number of blocks) known to work with the node,
+	// or 0 if the node has not rejected any range. Emitter uses this to shrink or grow its filter range.
+	FilterRangeLimit() uint64
+	// SetTrackerStore sets the TrackerStore used to persist tracked blocks across restarts.
+	// The tracked blocks are loaded from the store on the next call to Poll, and saved after every successful Poll.
+	SetTrackerStore(TrackerStore)
+	// SetRetryPolicy sets the RetryPolicy used to retry failed RPC calls. Nil means no retries.
+	SetRetryPolicy(*RetryPolicy)
+	// SetDiscovery sets the DiscoveryFunc used to discover new addresses and topics from polled logs.
+	// Discovered addresses are watched and backfilled in the same Poll, and are removed if their creating log is reorged.
+	SetDiscovery(DiscoveryFunc)
+	// AddLogPredicates adds LogPredicate(s) to the chain of predicates run on every polled log.
+	// Only logs matching all predicates are kept in Block.Logs.
+	AddLogPredicates(...LogPredicate)
+	// SetAddressChunkSize sets the max number of addresses per FilterLogs query. Larger address lists are split
+	// into chunks queried concurrently for the same range, and their logs are merged in order. 0 means no limit.
+	SetAddressChunkSize(uint64)
 
 	// EmitterPoller also implements Controller
 	Controller
@@ -43,9 +62,15 @@ const (
 	// regardless of whether the blocks have interesting logs or not, or Config.DoHeader value.
 	PolicyExpensive
 
-	// PolicyFastBlock behaves like PolicyExpensive, but instead of fetching
-	// event logs and headers, the poller fetches event logs and blocks.
+	// PolicyExpensiveBlock behaves like PolicyExpensive, but instead of fetching
+	// event logs and headers, the poller fetches event logs and full blocks
+	// (with transactions), and the transactions are attached to Block.Transactions.
 	PolicyExpensiveBlock
+
+	// PolicyHeaders makes poller process and track all blocks' headers like PolicyExpensive,
+	// but without polling any event logs, so that every canonical block is emitted with nil Block.Logs.
+	// It is for services that need a hook on every block, regardless of logs.
+	PolicyHeaders
 )
 
 func (level Policy) String() string {
@@ -58,6 +83,8 @@ func (level Policy) String() string {
 		return "EXPENSIVE"
 	case PolicyExpensiveBlock:
 		return "EXPENSIVE_BLOCK"
+	case PolicyHeaders:
+		return "HEADERS"
 	}
 
 	return fmt.Sprintf("UNKNOWN LEVEL %d", level)
@@ -11,11 +11,17 @@ var (
 	ErrChainIsReorging  = errors.New("chain is reorging and data is not usable for now")
 	ErrFromBlockReorged = errors.Wrap(ErrChainIsReorging, "fromBlock reorged")
 
+	// A block the poller had seen as finalized changed - the node or the chain's finality is broken,
+	// and the emitter will return, since the service may have acted on the finalized block.
+	ErrFinalizedBlockChanged = errors.New("finalized block changed")
+
 	// Bug from my own part
 	ErrSuperwatcherBug = errors.New("superwatcher bug")
 	ErrProcessReorg    = errors.Wrap(ErrSuperwatcherBug, "error in emitter reorg detection logic") // Bug in reorg detection logic
 
 	// User violates some rules/policies, e.g. downgrading poller Policy
-	ErrUserError = errors.New("user error")
-	ErrBadPolicy = errors.Wrap(ErrUserError, "invalid policy")
+	ErrUserError       = errors.New("user error")
+	ErrBadPolicy       = errors.Wrap(ErrUserError, "invalid policy")
+	ErrBadSubscription = errors.Wrap(ErrUserError, "invalid subscription")
+	ErrBadBackfill     = errors.Wrap(ErrUserError, "backfill workers cannot be used with DiscoveryFunc")
 )
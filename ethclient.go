@@ -7,7 +7,9 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
 )
 
 // EthClientRPC is used by poller to get data from client in batch,
@@ -24,6 +26,13 @@ type EthClient interface {
 	BlockNumber(context.Context) (uint64, error)
 	FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error)
 	HeaderByNumber(context.Context, *big.Int) (BlockHeader, error)
+	// SafeBlockNumber returns the number of the block with `safe` tag
+	SafeBlockNumber(context.Context) (uint64, error)
+	// FinalizedBlockNumber returns the number of the block with `finalized` tag
+	FinalizedBlockNumber(context.Context) (uint64, error)
+	// SubscribeNewHead subscribes to new chain heads with `eth_subscribe("newHeads")`.
+	// It returns error if the node does not support subscriptions, e.g. HTTP endpoints.
+	SubscribeNewHead(context.Context, chan<- BlockHeader) (ethereum.Subscription, error)
 	EthClientRPC // EthClient will need to be able to do batch RPC calls
 }
 
@@ -32,6 +41,7 @@ type ethClient interface {
 	BlockNumber(context.Context) (uint64, error)
 	FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error)
 	HeaderByNumber(context.Context, *big.Int) (*types.Header, error)
+	SubscribeNewHead(context.Context, chan<- *types.Header) (ethereum.Subscription, error)
 }
 
 // ethClientWrapper wraps *ethclient.Client to implement EthClient
@@ -60,18 +70,67 @@ func (w *ethClientWrapper) HeaderByNumber(ctx context.Context, number *big.Int)
 	}, nil
 }
 
+func (w *ethClientWrapper) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	return w.taggedBlockNumber(ctx, rpc.SafeBlockNumber)
+}
+
+func (w *ethClientWrapper) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return w.taggedBlockNumber(ctx, rpc.FinalizedBlockNumber)
+}
+
+// taggedBlockNumber gets the header of the block with |tag|, e.g. `safe` or `finalized`, and returns its number
+func (w *ethClientWrapper) taggedBlockNumber(ctx context.Context, tag rpc.BlockNumber) (uint64, error) {
+	h, err := w.client.HeaderByNumber(ctx, big.NewInt(tag.Int64()))
+	if err != nil {
+		return 0, err // nolint:wrapcheck
+	}
+
+	return h.Number.Uint64(), nil
+}
+
+// SubscribeNewHead forwards *types.Header from the node's newHeads subscription to |ch| as BlockHeader
+func (w *ethClientWrapper) SubscribeNewHead(ctx context.Context, ch chan<- BlockHeader) (ethereum.Subscription, error) {
+	headers := make(chan *types.Header)
+	sub, err := w.client.SubscribeNewHead(ctx, headers)
+	if err != nil {
+		return nil, err // nolint:wrapcheck
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case h := <-headers:
+				select {
+				case ch <- BlockHeaderWrapper{Header: h}:
+				case <-quit:
+					return nil
+				}
+
+			case err := <-sub.Err():
+				return err
+
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
 func (w *ethClientWrapper) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
 	return w.rpcClient.BatchCallContext(ctx, b) // nolint:wrapcheck
 }
 
-func NewEthClient(ctx context.Context, url string) EthClient {
+// NewEthClient dials |url| and returns EthClient backed by *ethclient.Client
+func NewEthClient(ctx context.Context, url string) (EthClient, error) {
 	rpcClient, err := rpc.DialContext(ctx, url)
 	if err != nil {
-		panic("failed to create new rpcClient " + err.Error())
+		return nil, errors.Wrapf(err, "failed to dial node %s", url)
 	}
 
 	return &ethClientWrapper{
 		rpcClient: rpcClient,
 		client:    ethclient.NewClient(rpcClient),
-	}
+	}, nil
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/soyart/superwatcher"
 	"github.com/soyart/superwatcher/pkg/components"
+	"github.com/soyart/superwatcher/pkg/trackerstore"
 )
 
 // This demo function calls components.NewDefault, which is the preferred way to init superwatcher for most cases.
@@ -48,6 +49,16 @@ func newSuperwatcherFromArgs( //nolint:unused
 	engine := components.NewEngine(emitterClient, serviceEngine, stateDataGateway, conf.LogLevel)
 	poller := components.NewPoller(nil, nil, conf.DoReorg, conf.DoHeader, conf.FilterRange, ethClient, conf.LogLevel, conf.Policy)
 
+	if conf.TrackerFile != "" {
+		poller.SetTrackerStore(trackerstore.NewFileStore(conf.TrackerFile))
+	}
+
+	poller.SetRetryPolicy(conf.Retry)
+	poller.SetDoParentHash(conf.DoParentHash)
+	poller.SetDoReceipts(conf.DoReceipts)
+	poller.SetDoFinality(conf.DoFinality)
+	poller.SetDoVerifyBlockHash(conf.DoVerifyBlockHash)
+
 	poller.SetAddresses(addresses)
 	poller.SetTopics([][]common.Hash{topics})
 
@@ -14,6 +14,7 @@ import (
 	// Most application/service code should only import these superwatcher packages, not `internal`.
 	"github.com/soyart/superwatcher"
 	"github.com/soyart/superwatcher/pkg/components"
+	"github.com/soyart/superwatcher/pkg/failover"
 	"github.com/soyart/superwatcher/pkg/logger"
 
 	"github.com/soyart/superwatcher/examples/demoservice/config"
@@ -86,10 +87,16 @@ func main() {
 		syscall.SIGTERM,
 	)
 
+	// Fail over between all configured node URLs
+	ethClient, err := failover.Dial(ctx, conf.SuperWatcherConfig.Endpoints(), failover.Options{LogLevel: conf.SuperWatcherConfig.LogLevel})
+	if err != nil {
+		panic("failed to dial eth nodes: " + err.Error())
+	}
+
 	// There are many ways to init superwatcher components. See package pkg/components
 	watcher := components.NewSuperWatcherOptions(
 		components.WithConfig(conf.SuperWatcherConfig),
-		components.WithEthClient(superwatcher.NewEthClient(ctx, conf.SuperWatcherConfig.NodeURL)),
+		components.WithEthClient(ethClient),
 		components.WithGetStateDataGateway(stateDataGateway),
 		components.WithSetStateDataGateway(stateDataGateway),
 		components.WithServiceEngine(demoEngine),
@@ -98,6 +105,8 @@ func main() {
 		components.WithErrChan(errChan),
 		components.WithAddresses(emitterAddresses...),
 		components.WithTopics(emitterTopics),
+		// Watch new Uniswap v3 pools as soon as the factory creates them
+		components.WithDiscovery(uniswapv3factoryengine.NewDiscovery(demoContracts[hardcode.Uniswapv3Factory], nil)),
 	)
 
 	if err := watcher.Run(ctx, cancel); err != nil {
@@ -0,0 +1,40 @@
+package uniswapv3factoryengine
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/soyart/w3utils"
+
+	"github.com/soyart/superwatcher"
+)
+
+// NewDiscovery returns superwatcher.DiscoveryFunc that maps PoolCreated logs from |poolFactoryContract|
+// to the new pool addresses, so that the poller starts watching the pools once they are created.
+// |poolTopics| is returned with every new pool, and may be nil if the poller already watches all topics.
+func NewDiscovery(
+	poolFactoryContract w3utils.Contract,
+	poolTopics []common.Hash,
+) superwatcher.DiscoveryFunc {
+	poolCreated, ok := poolFactoryContract.ContractABI.Events["PoolCreated"]
+	if !ok {
+		panic("PoolCreated event not found in uniswapv3factory ABI")
+	}
+
+	return func(log *types.Log) ([]common.Address, [][]common.Hash) {
+		if log.Address != poolFactoryContract.Address || len(log.Topics) == 0 || log.Topics[0] != poolCreated.ID {
+			return nil, nil
+		}
+
+		pool, err := mapLogToPoolCreated(poolFactoryContract.ContractABI, poolCreated.Name, log)
+		if err != nil {
+			return nil, nil
+		}
+
+		var topics [][]common.Hash
+		if len(poolTopics) != 0 {
+			topics = [][]common.Hash{poolTopics}
+		}
+
+		return []common.Address{pool.Address}, topics
+	}
+}
@@ -63,13 +63,18 @@ func main() {
 	uniswapV3Addr := []common.Address{common.HexToAddress("0x5777d92f208679DB4b9778590Fa3CAB3aC9e2168")}
 
 	ctx := context.Background()
+	ethClient, err := superwatcher.NewEthClient(ctx, nodeURL)
+	if err != nil {
+		panic("failed to dial eth node: " + err.Error())
+	}
+
 	s := &service{ctx: ctx, dataGateway: dataGateway}
 
 	emitter, engine := components.NewThinEngineWithEmitter(
 		conf,
 		dataGateway, dataGateway,
 		uniswapV3Addr, nil,
-		superwatcher.NewEthClient(ctx, nodeURL),
+		ethClient,
 		conf.Policy,
 		s,
 	)
@@ -0,0 +1,186 @@
+package emitter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+)
+
+// backfillChunk is a block range polled by a backfill worker
+type backfillChunk struct {
+	fromBlock uint64
+	toBlock   uint64
+}
+
+type backfillResult struct {
+	result *superwatcher.PollerResult
+	err    error
+}
+
+// backfill polls blocks from the last recorded block up to Config.BackfillSafetyMargin blocks behind the chain head
+// with Config.BackfillWorkers concurrent workers calling EmitterPoller.Backfill, and emits the results in block order,
+// syncing with the engine after each chunk. Because the engine saves each chunk's LastGoodBlock before the next chunk
+// is emitted, a restarted emitter resumes backfill right after the last chunk handled by the engine.
+// backfill returns without polling if the range is not larger than a single chunk, leaving it to loopEmit.
+func (e *emitter) backfill(ctx context.Context) error {
+	fromBlock, toBlock, err := e.backfillRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := e.conf.BackfillChunkSize
+	if chunkSize == 0 {
+		chunkSize = e.filterRange()
+	}
+
+	if toBlock < fromBlock || toBlock-fromBlock+1 <= chunkSize {
+		e.debugger.Debug(2, "skipping backfill", zap.Uint64("fromBlock", fromBlock), zap.Uint64("toBlock", toBlock))
+		return nil
+	}
+
+	chunks := backfillChunks(fromBlock, toBlock, chunkSize)
+	workers := int(e.conf.BackfillWorkers)
+
+	e.debugger.Debug(
+		1, "starting backfill",
+		zap.Uint64("fromBlock", fromBlock),
+		zap.Uint64("toBlock", toBlock),
+		zap.Int("chunks", len(chunks)),
+		zap.Int("workers", workers),
+	)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// Cancel workers before waiting for them
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan backfillResult, len(chunks))
+	for i := range results {
+		results[i] = make(chan backfillResult, 1)
+	}
+
+	// pending limits chunks polled but not yet emitted, so that a slow engine
+	// does not make the emitter buffer the whole history in memory
+	pending := make(chan struct{}, 2*workers)
+	jobs := make(chan int)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+
+		for i := range chunks {
+			select {
+			case pending <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				pollCtx, cancel := withTimeout(ctx, e.conf.PollTimeout)
+				result, err := e.poller.Backfill(pollCtx, chunks[i].fromBlock, chunks[i].toBlock)
+				cancel()
+
+				results[i] <- backfillResult{result: result, err: err}
+			}
+		}()
+	}
+
+	for i, chunk := range chunks {
+		var r backfillResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "exiting backfill")
+		}
+
+		if r.err != nil {
+			return errors.Wrapf(r.err, "failed to backfill blocks %d-%d", chunk.fromBlock, chunk.toBlock)
+		}
+
+		e.waitResume(ctx)
+		if err := e.emitAndSync(ctx, r.result); err != nil {
+			return err
+		}
+
+		e.breaker.succeeded()
+		<-pending
+	}
+
+	e.debugger.Debug(1, "backfill done, switching to normal polling", zap.Uint64("lastBackfilledBlock", toBlock))
+	return nil
+}
+
+// backfillRange returns the range to backfill, from the block after the last recorded block (or Config.StartBlock),
+// to Config.BackfillSafetyMargin blocks behind the newest confirmed block. toBlock is 0 if there's nothing to backfill.
+func (e *emitter) backfillRange(ctx context.Context) (uint64, uint64, error) {
+	fromBlock := e.conf.StartBlock
+	callCtx, cancel := withTimeout(ctx, e.conf.CallTimeout)
+	lastRecordedBlock, err := e.stateDataGateway.GetLastRecordedBlock(callCtx)
+	cancel()
+
+	if err != nil {
+		if !errors.Is(err, superwatcher.ErrRecordNotFound) {
+			return 0, 0, errors.Wrap(err, "failed to get last recorded block for backfill")
+		}
+	} else if lastRecordedBlock+1 > fromBlock {
+		fromBlock = lastRecordedBlock + 1
+	}
+
+	var currentBlock uint64
+	err = e.conf.Retry.Do(ctx, func() error {
+		callCtx, cancel := withTimeout(ctx, e.conf.CallTimeout)
+		defer cancel()
+
+		currentBlock, err = e.client.BlockNumber(callCtx)
+		return err
+	})
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to get current block number from node for backfill")
+	}
+
+	head := confirmedBlock(currentBlock, e.conf.Confirmations)
+	if head <= e.conf.BackfillSafetyMargin {
+		return fromBlock, 0, nil
+	}
+
+	return fromBlock, head - e.conf.BackfillSafetyMargin, nil
+}
+
+// backfillChunks splits |fromBlock| to |toBlock| into chunks of |chunkSize| blocks
+func backfillChunks(
+	fromBlock uint64,
+	toBlock uint64,
+	chunkSize uint64,
+) []backfillChunk {
+	var chunks []backfillChunk
+	for from := fromBlock; from <= toBlock; from += chunkSize {
+		to := from + chunkSize - 1
+		if to > toBlock {
+			to = toBlock
+		}
+
+		chunks = append(chunks, backfillChunk{fromBlock: from, toBlock: to})
+	}
+
+	return chunks
+}
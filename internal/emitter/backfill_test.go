@@ -0,0 +1,96 @@
+package emitter
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/soyart/superwatcher"
+)
+
+// backfillPoller returns results for Backfill calls after random delays
+type backfillPoller struct {
+	superwatcher.EmitterPoller
+}
+
+func (p *backfillPoller) Backfill(_ context.Context, fromBlock, toBlock uint64) (*superwatcher.PollerResult, error) {
+	time.Sleep(time.Millisecond * time.Duration(rand.Intn(10)))
+
+	result := &superwatcher.PollerResult{FromBlock: fromBlock, ToBlock: toBlock, LastGoodBlock: toBlock}
+	for n := fromBlock; n <= toBlock; n++ {
+		result.GoodBlocks = append(result.GoodBlocks, &superwatcher.Block{Number: n})
+	}
+
+	return result, nil
+}
+
+func (p *backfillPoller) FilterRangeLimit() uint64 { return 0 }
+
+type blockNumberClient struct {
+	superwatcher.EthClient
+	currentBlock uint64
+}
+
+func (c *blockNumberClient) BlockNumber(context.Context) (uint64, error) { return c.currentBlock, nil }
+
+func TestBackfillChunks(t *testing.T) {
+	chunks := backfillChunks(10, 34, 10)
+	expected := []backfillChunk{{10, 19}, {20, 29}, {30, 34}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expecting %d chunks, got %d", len(expected), len(chunks))
+	}
+
+	for i := range expected {
+		if chunks[i] != expected[i] {
+			t.Fatalf("expecting chunk %v, got %v", expected[i], chunks[i])
+		}
+	}
+}
+
+// TestBackfill checks that backfill emits results in block order,
+// starting after the last recorded block and stopping at the safety margin.
+func TestBackfill(t *testing.T) {
+	conf := &superwatcher.Config{
+		StartBlock:           100,
+		FilterRange:          10,
+		BackfillWorkers:      4,
+		BackfillChunkSize:    7,
+		BackfillSafetyMargin: 20,
+	}
+
+	var lastRecordedBlock uint64 = 149
+	stateDataGateway := superwatcher.GetStateDataGatewayFunc(func(context.Context) (uint64, error) {
+		return lastRecordedBlock, nil
+	})
+
+	syncChan := make(chan struct{})
+	pollResultChan := make(chan *superwatcher.PollerResult)
+	e := New(conf, &blockNumberClient{currentBlock: 500}, stateDataGateway, &backfillPoller{}, syncChan, pollResultChan, make(chan error)).(*emitter)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- e.backfill(context.Background())
+		close(pollResultChan)
+	}()
+
+	next := lastRecordedBlock + 1
+	for result := range pollResultChan {
+		for _, b := range result.GoodBlocks {
+			if b.Number != next {
+				t.Fatalf("expecting block %d, got %d", next, b.Number)
+			}
+			next++
+		}
+
+		syncChan <- struct{}{}
+	}
+
+	if err := <-errChan; err != nil {
+		t.Fatal("unexpected backfill error", err.Error())
+	}
+
+	if expected := uint64(500 - 20); next-1 != expected {
+		t.Fatalf("expecting backfill to stop at %d, stopped at %d", expected, next-1)
+	}
+}
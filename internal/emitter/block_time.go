@@ -0,0 +1,170 @@
+package emitter
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// blockTimeWindow is the number of blocks over which blockTimer measures the block time.
+// Header timestamps are in seconds, so the window must span many blocks on chains with sub-second blocks.
+const blockTimeWindow = 16
+
+// overdueFraction is the fraction of the block time to wait if the next block is already overdue
+const overdueFraction = 8
+
+// headTime is a chain head seen by the emitter, with its header timestamp
+type headTime struct {
+	number uint64
+	time   uint64
+}
+
+// blockTimer measures the chain's block time from header timestamps of the chain heads seen by the emitter
+type blockTimer struct {
+	client   superwatcher.EthClient
+	heads    []headTime // Oldest first, spanning at least blockTimeWindow blocks if possible
+	debugger *debugger.Debugger
+}
+
+func newBlockTimer(client superwatcher.EthClient, logLevel uint8) *blockTimer {
+	return &blockTimer{
+		client:   client,
+		debugger: debugger.NewDebugger("emitter blockTimer", logLevel),
+	}
+}
+
+// observe records the header timestamp of chain head |number|. On the first call, it also gets the header
+// blockTimeWindow blocks before |number|, so that the block time is known after the first loop.
+func (b *blockTimer) observe(ctx context.Context, number uint64) error {
+	if len(b.heads) != 0 && b.heads[len(b.heads)-1].number >= number {
+		return nil
+	}
+
+	if len(b.heads) == 0 && number > blockTimeWindow {
+		if err := b.add(ctx, number-blockTimeWindow); err != nil {
+			return err
+		}
+	}
+
+	return b.add(ctx, number)
+}
+
+func (b *blockTimer) add(ctx context.Context, number uint64) error {
+	header, err := b.client.HeaderByNumber(ctx, big.NewInt(int64(number)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get header %d for block time", number)
+	}
+	if header == nil {
+		return errors.Errorf("nil header %d for block time", number)
+	}
+
+	b.heads = append(b.heads, headTime{number: number, time: header.Time()})
+
+	// Drop the oldest head if the remaining heads still span blockTimeWindow blocks
+	newest := b.heads[len(b.heads)-1].number
+	for len(b.heads) > 2 && newest-b.heads[1].number >= blockTimeWindow {
+		b.heads = b.heads[1:]
+	}
+
+	return nil
+}
+
+// blockTime returns the average block time of the observed heads, or 0 if it is not known yet
+func (b *blockTimer) blockTime() time.Duration {
+	if len(b.heads) < 2 {
+		return 0
+	}
+
+	oldest, newest := b.heads[0], b.heads[len(b.heads)-1]
+	if newest.time <= oldest.time {
+		return 0
+	}
+
+	return time.Second * time.Duration(newest.time-oldest.time) / time.Duration(newest.number-oldest.number)
+}
+
+// untilNext returns the time until the block after the newest observed head is expected,
+// which is at most |blockTime|. If that block is already overdue, it returns |blockTime|/overdueFraction.
+// Header timestamps are in seconds, so it may be shorter than necessary.
+func (b *blockTimer) untilNext(blockTime time.Duration) time.Duration {
+	newest := b.heads[len(b.heads)-1]
+	next := time.Unix(int64(newest.time), 0).Add(blockTime)
+
+	until := time.Until(next)
+	switch {
+	case until <= 0:
+		return blockTime / overdueFraction
+	case until < blockTime:
+		return until
+	}
+
+	return blockTime
+}
+
+// interval returns how long loopEmit sleeps before the next loop, with Config.IntervalJitter.
+// With Config.AdaptiveInterval, it is the time until the next block is expected, divided by the number of
+// loops needed to catch up with the chain if the emitter is more than 1 filter range behind.
+// Without a known block time, Config.Interval() is used.
+func (e *emitter) interval(ctx context.Context, status *emitterStatus) time.Duration {
+	interval := e.conf.Interval()
+	if e.blockTimer != nil && status.CurrentBlock != 0 {
+		interval = e.adaptiveInterval(ctx, status, interval)
+	}
+
+	return withJitter(interval, e.conf.IntervalJitter)
+}
+
+func (e *emitter) adaptiveInterval(
+	ctx context.Context,
+	status *emitterStatus,
+	maxInterval time.Duration,
+) time.Duration {
+	callCtx, cancel := withTimeout(ctx, e.conf.CallTimeout)
+	err := e.blockTimer.observe(callCtx, status.CurrentBlock)
+	cancel()
+
+	if err != nil {
+		e.debugger.Warn(1, "failed to measure block time", zap.String("error", err.Error()))
+	}
+
+	blockTime := e.blockTimer.blockTime()
+	if blockTime == 0 {
+		return maxInterval
+	}
+
+	interval := e.blockTimer.untilNext(blockTime)
+	if maxInterval != 0 && interval > maxInterval {
+		interval = maxInterval
+	}
+
+	// Back off less when far behind the chain head
+	if status.FilterRange != 0 && status.ConfirmedBlock > status.ToBlock {
+		if loops := (status.ConfirmedBlock - status.ToBlock) / status.FilterRange; loops > 1 {
+			interval /= time.Duration(loops)
+		}
+	}
+
+	e.debugger.Debug(
+		3, "adaptive interval",
+		zap.Duration("blockTime", blockTime),
+		zap.Duration("interval", interval),
+	)
+
+	return interval
+}
+
+// withJitter randomizes |d| by up to ±|jitter| (0-1) of its value
+func withJitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+
+	return d + time.Duration(float64(d)*jitter*(2*rand.Float64()-1))
+}
@@ -0,0 +1,120 @@
+package emitter
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/soyart/superwatcher"
+)
+
+// blockTimeClient is a chain producing a block every 250ms since genesis, whose header timestamps are in seconds
+type blockTimeClient struct {
+	superwatcher.EthClient
+	genesis uint64
+	calls   int
+}
+
+func (c *blockTimeClient) HeaderByNumber(_ context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
+	c.calls++
+	return superwatcher.BlockHeaderWrapper{
+		Header: &types.Header{Number: number, Time: c.genesis + number.Uint64()/4},
+	}, nil
+}
+
+func TestBlockTimer(t *testing.T) {
+	client := &blockTimeClient{genesis: 1000}
+	timer := newBlockTimer(client, 1)
+
+	if blockTime := timer.blockTime(); blockTime != 0 {
+		t.Fatalf("expecting unknown block time, got %s", blockTime)
+	}
+
+	ctx := context.Background()
+	if err := timer.observe(ctx, 100); err != nil {
+		t.Fatal("unexpected observe error", err.Error())
+	}
+
+	// The first observation also gets the header blockTimeWindow blocks back
+	if client.calls != 2 {
+		t.Fatalf("expecting 2 header calls, got %d", client.calls)
+	}
+
+	if blockTime := timer.blockTime(); blockTime != 250*time.Millisecond {
+		t.Fatalf("expecting block time 250ms, got %s", blockTime)
+	}
+
+	// Old heads are not fetched again
+	if err := timer.observe(ctx, 100); err != nil {
+		t.Fatal("unexpected observe error", err.Error())
+	}
+	if client.calls != 2 {
+		t.Fatalf("expecting no more header calls, got %d", client.calls)
+	}
+
+	for number := uint64(104); number <= 200; number += 4 {
+		if err := timer.observe(ctx, number); err != nil {
+			t.Fatal("unexpected observe error", err.Error())
+		}
+	}
+
+	if oldest := timer.heads[0].number; 200-oldest < blockTimeWindow || 200-oldest > blockTimeWindow+4 {
+		t.Fatalf("unexpected oldest head %d", oldest)
+	}
+
+	if blockTime := timer.blockTime(); blockTime != 250*time.Millisecond {
+		t.Fatalf("expecting block time 250ms, got %s", blockTime)
+	}
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	conf := &superwatcher.Config{LoopInterval: 2, AdaptiveInterval: true}
+	// Blocks in the future, so that the next block is never overdue
+	e := New(conf, &blockTimeClient{genesis: uint64(time.Now().Unix()) + 60}, nil, nil, nil, nil, nil).(*emitter)
+
+	// Use Config.Interval() before the first loop
+	if interval := e.interval(context.Background(), new(emitterStatus)); interval != 2*time.Second {
+		t.Fatalf("expecting 2s before the first loop, got %s", interval)
+	}
+
+	status := &emitterStatus{CurrentBlock: 100, ConfirmedBlock: 100, ToBlock: 100, FilterRange: 10}
+	if interval := e.interval(context.Background(), status); interval != 250*time.Millisecond {
+		t.Fatalf("expecting 250ms, got %s", interval)
+	}
+
+	// 5 filter ranges behind the chain head
+	status = &emitterStatus{CurrentBlock: 148, ConfirmedBlock: 148, ToBlock: 98, FilterRange: 10}
+	if interval := e.interval(context.Background(), status); interval != 50*time.Millisecond {
+		t.Fatalf("expecting 50ms when behind, got %s", interval)
+	}
+
+	// Config.Interval() caps the adaptive interval
+	conf.PollInterval = 100 * time.Millisecond
+	status = &emitterStatus{CurrentBlock: 148, ConfirmedBlock: 148, ToBlock: 148, FilterRange: 10}
+	if interval := e.interval(context.Background(), status); interval != 100*time.Millisecond {
+		t.Fatalf("expecting 100ms cap, got %s", interval)
+	}
+
+	conf.IntervalJitter = 0.5
+	for i := 0; i < 100; i++ {
+		if interval := e.interval(context.Background(), status); interval < 50*time.Millisecond || interval > 150*time.Millisecond {
+			t.Fatalf("interval %s out of jitter range", interval)
+		}
+	}
+}
+
+func TestUntilNextOverdue(t *testing.T) {
+	timer := newBlockTimer(&blockTimeClient{genesis: 1000}, 1)
+	if err := timer.observe(context.Background(), 100); err != nil {
+		t.Fatal("unexpected observe error", err.Error())
+	}
+
+	// Block 101 was due long ago, so poll again soon instead of waiting for a full block time
+	blockTime := timer.blockTime()
+	if until := timer.untilNext(blockTime); until != blockTime/overdueFraction {
+		t.Fatalf("expecting %s for overdue block, got %s", blockTime/overdueFraction, until)
+	}
+}
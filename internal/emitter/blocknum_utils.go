@@ -114,3 +114,15 @@ func fromBlockToBlockGoBackFirstRun(
 
 	return fromBlock, toBlock, goBack
 }
+
+// confirmedBlock returns the newest block with |confirmations| confirmations, i.e. currentBlock - confirmations.
+func confirmedBlock(
+	currentBlock uint64,
+	confirmations uint64,
+) uint64 {
+	if confirmations > currentBlock {
+		return 0
+	}
+
+	return currentBlock - confirmations
+}
@@ -0,0 +1,91 @@
+package emitter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// circuitBreaker counts consecutive loopEmit failures caused by transient errors. The errors are only
+// surfaced to the engine once the breaker opens after policy.MaxFailures failures, and the breaker
+// stays open (not surfacing more errors) until loopEmit succeeds again.
+type circuitBreaker struct {
+	policy   *superwatcher.RetryPolicy
+	failures int
+	open     bool
+	debugger *debugger.Debugger
+}
+
+// failed records |err| from loopEmit, and returns the error to be sent to the engine, or nil if the error
+// should not be surfaced. If |policy| is nil, or |err| is not retryable, |err| is always returned.
+func (b *circuitBreaker) failed(err error) error {
+	if b.policy == nil || errors.Is(err, ErrMaxRetriesReached) || !b.policy.IsRetryable(err) {
+		return err
+	}
+
+	b.failures++
+	if b.open {
+		b.debugger.Debug(2, "circuit breaker still open", zap.Int("failures", b.failures), zap.String("error", err.Error()))
+		return nil
+	}
+
+	maxFailures := b.policy.MaxFailures
+	if maxFailures < 1 {
+		maxFailures = 1
+	}
+
+	if b.failures < maxFailures {
+		b.debugger.Warn(1, "loopEmit failed, retrying", zap.Int("failures", b.failures), zap.String("error", err.Error()))
+		return nil
+	}
+
+	b.open = true
+	return &circuitOpenError{failures: b.failures, err: err}
+}
+
+// circuitOpenError is returned when the breaker opens. It matches ErrCircuitOpen with errors.Is,
+// while keeping the last loopEmit error in the chain, so that callers can still match its cause.
+type circuitOpenError struct {
+	failures int
+	err      error
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %d consecutive failures, last error: %s", ErrCircuitOpen.Error(), e.failures, e.err.Error())
+}
+
+func (e *circuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+func (e *circuitOpenError) Unwrap() error {
+	return e.err
+}
+
+// succeeded closes the breaker and resets its failure count.
+func (b *circuitBreaker) succeeded() {
+	if b.failures != 0 {
+		b.debugger.Debug(1, "circuit breaker closed", zap.Int("failures", b.failures))
+	}
+
+	b.failures = 0
+	b.open = false
+}
+
+// wait sleeps for the policy backoff after the last failure, or until |ctx| is done.
+func (b *circuitBreaker) wait(ctx context.Context) {
+	if b.policy == nil || b.failures == 0 {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(b.policy.Backoff(b.failures)):
+	}
+}
@@ -0,0 +1,59 @@
+package emitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := &circuitBreaker{
+		policy:   &superwatcher.RetryPolicy{InitialBackoff: time.Millisecond, MaxFailures: 3},
+		debugger: debugger.NewDebugger("testCircuitBreaker", 1),
+	}
+
+	fetchErr := errors.Wrap(superwatcher.ErrFetchError, "connection refused")
+
+	// Only 1 error is surfaced, after MaxFailures consecutive failures
+	var surfaced int
+	for i := 0; i < 10; i++ {
+		if err := b.failed(fetchErr); err != nil {
+			if !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("expecting ErrCircuitOpen, got %s", err.Error())
+			}
+			if !errors.Is(err, superwatcher.ErrFetchError) {
+				t.Fatalf("expecting last error to be kept in chain, got %s", err.Error())
+			}
+			if i != 2 {
+				t.Fatalf("expecting error to be surfaced on failure 3, got failure %d", i+1)
+			}
+
+			surfaced++
+		}
+	}
+
+	if surfaced != 1 {
+		t.Fatalf("expecting 1 surfaced error, got %d", surfaced)
+	}
+
+	// Non-transient errors are always surfaced
+	if err := b.failed(errors.Wrap(superwatcher.ErrSuperwatcherBug, "bug")); !errors.Is(err, superwatcher.ErrSuperwatcherBug) {
+		t.Fatalf("expecting ErrSuperwatcherBug, got %v", err)
+	}
+
+	// Success closes the breaker
+	b.succeeded()
+	if err := b.failed(fetchErr); err != nil {
+		t.Fatalf("unexpected error after breaker closed: %s", err.Error())
+	}
+
+	// Without policy, all errors are surfaced
+	b = &circuitBreaker{debugger: debugger.NewDebugger("testCircuitBreaker", 1)}
+	if err := b.failed(fetchErr); err == nil {
+		t.Fatal("expecting error without policy")
+	}
+}
@@ -0,0 +1,208 @@
+package emitter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/soyart/gsl"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+)
+
+// control holds requests from Pause, Resume, SeekTo and Rewind, which loopEmit applies between loops
+type control struct {
+	sync.Mutex
+
+	resumed chan struct{} // Closed by Resume, nil if not paused
+	seek    *seekRequest  // Pending seek, the latest request wins
+}
+
+// seekRequest is a pending SeekTo or Rewind
+type seekRequest struct {
+	block  uint64 // Block to seek to, if rewind is 0
+	rewind uint64 // Number of recorded blocks to rewind
+}
+
+// Pause stops loopEmit from polling after the current loop, until Resume is called.
+// Pending seeks are applied after Resume.
+func (e *emitter) Pause() {
+	e.control.Lock()
+	defer e.control.Unlock()
+
+	if e.control.resumed == nil {
+		e.debugger.Debug(1, "pausing emitter")
+		e.control.resumed = make(chan struct{})
+	}
+}
+
+// Resume resumes loopEmit after Pause
+func (e *emitter) Resume() {
+	e.control.Lock()
+	defer e.control.Unlock()
+
+	if e.control.resumed != nil {
+		e.debugger.Debug(1, "resuming emitter")
+		close(e.control.resumed)
+		e.control.resumed = nil
+	}
+}
+
+// SeekTo makes loopEmit continue from |block| in the next loop, see superwatcher.Emitter.
+func (e *emitter) SeekTo(block uint64) error {
+	if block < e.conf.StartBlock {
+		return errors.Wrapf(ErrSeekBeforeStartBlock, "block %d, startBlock %d", block, e.conf.StartBlock)
+	}
+
+	e.control.Lock()
+	defer e.control.Unlock()
+
+	e.control.seek = &seekRequest{block: block}
+	return nil
+}
+
+// Rewind makes loopEmit reprocess the last |n| recorded blocks in the next loop, see superwatcher.Emitter.
+func (e *emitter) Rewind(n uint64) {
+	if n == 0 {
+		return
+	}
+
+	e.control.Lock()
+	defer e.control.Unlock()
+
+	e.control.seek = &seekRequest{rewind: n}
+}
+
+// waitResume blocks while the emitter is paused, until Resume is called or |ctx| is done
+func (e *emitter) waitResume(ctx context.Context) {
+	e.control.Lock()
+	resumed := e.control.resumed
+	e.control.Unlock()
+
+	if resumed == nil {
+		return
+	}
+
+	e.debugger.Debug(1, "emitter paused, waiting for Resume")
+	select {
+	case <-resumed:
+	case <-ctx.Done():
+	}
+}
+
+// applySeek applies the pending seek, if any, and returns whether a seek was applied.
+// Blocks from the seek block to the last recorded block are rewound with EmitterPoller.Rewind, and emitted
+// as ReorgedBlocks with LastGoodBlock set to the block before the seek block. When the engine saves it,
+// the next loop polls from the seek block as if the emitter was restarted there, so the blocks are emitted again.
+// Seeking forward emits a result without blocks, so that the engine saves the new last recorded block.
+// Forward seeks are clamped to the block after the confirmed block, since newer blocks cannot be skipped yet.
+func (e *emitter) applySeek(ctx context.Context, status *emitterStatus) (bool, error) {
+	e.control.Lock()
+	req := e.control.seek
+	e.control.Unlock()
+
+	if req == nil {
+		return false, nil
+	}
+
+	callCtx, cancel := withTimeout(ctx, e.conf.CallTimeout)
+	lastRecordedBlock, err := e.stateDataGateway.GetLastRecordedBlock(callCtx)
+	cancel()
+
+	if err != nil {
+		if !errors.Is(err, superwatcher.ErrRecordNotFound) {
+			return false, errors.Wrap(err, "failed to get last recorded block for seek")
+		}
+
+		lastRecordedBlock = e.conf.StartBlock
+	}
+
+	block := req.block
+	if req.rewind != 0 {
+		block = e.conf.StartBlock
+		if lastRecordedBlock+1 > e.conf.StartBlock+req.rewind {
+			block = lastRecordedBlock + 1 - req.rewind
+		}
+	}
+
+	// Blocks after the confirmed block cannot be skipped yet
+	if block > lastRecordedBlock+1 {
+		var currentBlock uint64
+		err = e.conf.Retry.Do(ctx, func() error {
+			callCtx, cancel := withTimeout(ctx, e.conf.CallTimeout)
+			defer cancel()
+
+			currentBlock, err = e.client.BlockNumber(callCtx)
+			return err
+		})
+		if err != nil {
+			return false, errors.Wrap(err, "failed to get current block number from node for seek")
+		}
+
+		if maxBlock := confirmedBlock(currentBlock, e.conf.Confirmations) + 1; block > maxBlock {
+			e.debugger.Warn(
+				1, "seek block is after confirmed block, clamping",
+				zap.Uint64("block", block),
+				zap.Uint64("maxBlock", maxBlock),
+			)
+
+			block = gsl.Max(maxBlock, lastRecordedBlock+1)
+		}
+	}
+
+	// The emitter already continues from block, so there's nothing to emit
+	if block == lastRecordedBlock+1 {
+		e.debugger.Debug(1, "seek block is the next block, ignoring", zap.Uint64("block", block))
+		e.clearSeek(req)
+
+		return false, nil
+	}
+
+	var result *superwatcher.PollerResult
+	if block <= lastRecordedBlock {
+		pollCtx, cancel := withTimeout(ctx, e.conf.PollTimeout)
+		result, err = e.poller.Rewind(pollCtx, block, lastRecordedBlock)
+		cancel()
+
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to rewind blocks %d-%d", block, lastRecordedBlock)
+		}
+	} else {
+		result = &superwatcher.PollerResult{
+			FromBlock:     lastRecordedBlock + 1,
+			ToBlock:       block - 1,
+			LastGoodBlock: block - 1,
+		}
+	}
+
+	e.debugger.Debug(
+		1, "seeking",
+		zap.Uint64("block", block),
+		zap.Uint64("lastRecordedBlock", lastRecordedBlock),
+		zap.Int("rewoundBlocks", len(result.ReorgedBlocks)),
+	)
+
+	if err := e.emitAndSync(ctx, result); err != nil {
+		return false, err
+	}
+
+	e.clearSeek(req)
+
+	// Poll from block without looking back in the next loop
+	status.GoBackFirstStart = true
+	status.IsReorging = false
+	status.RetriesCount = 0
+
+	return true, nil
+}
+
+// clearSeek removes |req| after it was applied, keeping newer requests made while seeking
+func (e *emitter) clearSeek(req *seekRequest) {
+	e.control.Lock()
+	defer e.control.Unlock()
+
+	if e.control.seek == req {
+		e.control.seek = nil
+	}
+}
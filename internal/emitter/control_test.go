@@ -0,0 +1,165 @@
+package emitter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+// rewindPoller is resultPoller that rewinds blocks as ReorgedBlocks
+type rewindPoller struct {
+	resultPoller
+}
+
+func (rewindPoller) Rewind(_ context.Context, fromBlock, toBlock uint64) (*superwatcher.PollerResult, error) {
+	result := &superwatcher.PollerResult{FromBlock: fromBlock, ToBlock: toBlock, LastGoodBlock: fromBlock - 1, Rewind: true}
+	for n := fromBlock; n <= toBlock; n++ {
+		result.ReorgedBlocks = append(result.ReorgedBlocks, &superwatcher.Block{Number: n})
+	}
+
+	return result, nil
+}
+
+// controlTest runs an emitter with a fake engine, which saves LastGoodBlock of every result
+type controlTest struct {
+	emitter           *emitter
+	results           chan *superwatcher.PollerResult
+	lastRecordedBlock atomic.Uint64
+}
+
+func newControlTest(lastRecordedBlock uint64) *controlTest {
+	conf := &superwatcher.Config{StartBlock: 1, FilterRange: 10, PollInterval: time.Millisecond}
+	c := &controlTest{results: make(chan *superwatcher.PollerResult, 100)}
+	c.lastRecordedBlock.Store(lastRecordedBlock)
+
+	stateDataGateway := superwatcher.GetStateDataGatewayFunc(func(context.Context) (uint64, error) {
+		return c.lastRecordedBlock.Load(), nil
+	})
+
+	syncChan := make(chan struct{})
+	pollResultChan := make(chan *superwatcher.PollerResult)
+	c.emitter = New(conf, &blockNumberClient{currentBlock: 200}, stateDataGateway, rewindPoller{}, syncChan, pollResultChan, make(chan error)).(*emitter)
+
+	go func() {
+		for result := range pollResultChan {
+			c.lastRecordedBlock.Store(result.LastGoodBlock)
+			c.results <- result
+			syncChan <- struct{}{}
+		}
+	}()
+
+	return c
+}
+
+func (c *controlTest) start(ctx context.Context) {
+	go c.emitter.Loop(ctx) //nolint:errcheck
+}
+
+func (c *controlTest) next(t *testing.T) *superwatcher.PollerResult {
+	select {
+	case result := <-c.results:
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for result")
+		return nil
+	}
+}
+
+func TestPause(t *testing.T) {
+	c := newControlTest(100)
+	c.emitter.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.start(ctx)
+
+	select {
+	case result := <-c.results:
+		t.Fatalf("unexpected result %d-%d while paused", result.FromBlock, result.ToBlock)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.emitter.Resume()
+	if result := c.next(t); result.FromBlock != 101 {
+		t.Fatalf("expecting first poll from block 101, got %d", result.FromBlock)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	c := newControlTest(100)
+
+	// Seek before the emitter starts, so that the seek is applied on the first loop
+	c.emitter.Rewind(10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.start(ctx)
+
+	result := c.next(t)
+	if !result.Rewind || len(result.ReorgedBlocks) != 10 || result.ReorgedBlocks[0].Number != 91 || result.LastGoodBlock != 90 {
+		t.Fatalf("unexpected rewind result %+v", result)
+	}
+
+	// Polling resumes from the first rewound block without looking back
+	if result := c.next(t); result.FromBlock != 91 || result.ToBlock != 100 {
+		t.Fatalf("expecting poll 91-100 after rewind, got %d-%d", result.FromBlock, result.ToBlock)
+	}
+}
+
+func TestSeekForward(t *testing.T) {
+	c := newControlTest(100)
+	if err := c.emitter.SeekTo(150); err != nil {
+		t.Fatal("unexpected SeekTo error", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.start(ctx)
+
+	// Seeking forward skips blocks
+	result := c.next(t)
+	if len(result.ReorgedBlocks)+len(result.GoodBlocks) != 0 || result.LastGoodBlock != 149 {
+		t.Fatalf("unexpected seek result %+v", result)
+	}
+
+	if result := c.next(t); result.FromBlock != 150 || result.ToBlock != 159 {
+		t.Fatalf("expecting poll 150-159 after seek, got %d-%d", result.FromBlock, result.ToBlock)
+	}
+
+	if err := c.emitter.SeekTo(0); !errors.Is(err, ErrSeekBeforeStartBlock) {
+		t.Fatalf("expecting ErrSeekBeforeStartBlock, got %v", err)
+	}
+}
+
+func TestSeekBounds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Seeking to the next block is a no-op
+	c := newControlTest(100)
+	if err := c.emitter.SeekTo(101); err != nil {
+		t.Fatal("unexpected SeekTo error", err.Error())
+	}
+
+	c.start(ctx)
+	if result := c.next(t); result.FromBlock != 101 || result.ToBlock != 110 {
+		t.Fatalf("expecting poll 101-110 after seeking to the next block, got %d-%d", result.FromBlock, result.ToBlock)
+	}
+
+	// Seeking past the chain head (block 200) is clamped to the block after it
+	c = newControlTest(100)
+	if err := c.emitter.SeekTo(1000); err != nil {
+		t.Fatal("unexpected SeekTo error", err.Error())
+	}
+
+	c.start(ctx)
+	result := c.next(t)
+	if len(result.ReorgedBlocks)+len(result.GoodBlocks) != 0 || result.FromBlock != 101 || result.ToBlock != 200 || result.LastGoodBlock != 200 {
+		t.Fatalf("unexpected clamped seek result %+v", result)
+	}
+}
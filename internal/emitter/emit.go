@@ -1,13 +1,26 @@
 package emitter
 
 import (
+	"context"
+
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
 	"github.com/soyart/superwatcher"
 	"github.com/soyart/superwatcher/pkg/logger"
 )
 
-func (e *emitter) emitFilterResult(result *superwatcher.PollerResult) {
+// emitAndSync emits |result| and waits for the engine to sync, returning early with an error if |ctx| is done.
+func (e *emitter) emitAndSync(ctx context.Context, result *superwatcher.PollerResult) error {
+	if err := e.emitFilterResult(ctx, result); err != nil {
+		return err
+	}
+
+	return e.syncEngine(ctx)
+}
+
+// emitFilterResult sends |result| to the engine, or returns an error if |ctx| is done before the engine receives it.
+func (e *emitter) emitFilterResult(ctx context.Context, result *superwatcher.PollerResult) error {
 	if result != nil {
 		// Only log if there's some logs
 		nilChan := e.pollResultChan == nil
@@ -34,13 +47,18 @@ func (e *emitter) emitFilterResult(result *superwatcher.PollerResult) {
 		}
 
 		if !nilChan {
-			e.pollResultChan <- result
+			select {
+			case e.pollResultChan <- result:
+			case <-ctx.Done():
+				return errors.Wrap(ctx.Err(), "emitFilterResult canceled")
+			}
 		}
 
-		return
+		return nil
 	}
 
 	logger.Panic("nil PollerResult got sent to emitFilterREsult")
+	return nil
 }
 
 func (e *emitter) emitError(err error) {
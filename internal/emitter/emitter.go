@@ -34,6 +34,18 @@ type emitter struct {
 	errChan        chan<- error                      // Channel used to send emitter/emitterPoller errors
 	syncChan       <-chan struct{}                   // Channel used to sync with consumer
 
+	// breaker keeps transient errors from reaching the engine until conf.Retry.MaxFailures is reached
+	breaker *circuitBreaker
+
+	// heads wakes loopEmit on new heads if conf.SubscribeNewHeads is true, otherwise nil
+	heads *newHeads
+
+	// control holds pending Pause, Resume, SeekTo and Rewind requests
+	control control
+
+	// blockTimer measures the block time for the loop interval if conf.AdaptiveInterval is true, otherwise nil
+	blockTimer *blockTimer
+
 	// emitter.debug allows us to check if we should calls debugger when debugging in a large for loop.
 	// This should save some CPU time.
 	debug    bool
@@ -49,6 +61,16 @@ func New(
 	pollResultChan chan<- *superwatcher.PollerResult,
 	errChan chan<- error,
 ) superwatcher.Emitter {
+	var heads *newHeads
+	if conf.SubscribeNewHeads {
+		heads = newNewHeads(client, conf.Interval(), conf.LogLevel)
+	}
+
+	var blockTimer *blockTimer
+	if conf.AdaptiveInterval {
+		blockTimer = newBlockTimer(client, conf.LogLevel)
+	}
+
 	return &emitter{
 		conf:             conf,
 		client:           client,
@@ -59,6 +81,12 @@ func New(
 		errChan:          errChan,
 		debug:            conf.LogLevel > 0,
 		debugger:         debugger.NewDebugger("emitter", conf.LogLevel),
+		heads:            heads,
+		blockTimer:       blockTimer,
+		breaker: &circuitBreaker{
+			policy:   conf.Retry,
+			debugger: debugger.NewDebugger("emitter breaker", conf.LogLevel),
+		},
 	}
 }
 
@@ -81,6 +109,10 @@ func (e *emitter) SetPoller(poller superwatcher.EmitterPoller) {
 func (e *emitter) Loop(ctx context.Context) error {
 	status := new(emitterStatus)
 
+	if e.heads != nil {
+		go e.heads.loop(ctx)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -91,7 +123,18 @@ func (e *emitter) Loop(ctx context.Context) error {
 		default:
 			if err := e.loopEmit(ctx, status); err != nil {
 				e.debugger.Debug(1, "loopEmit returned", zap.Any("status", status), zap.Error(err))
-				e.emitError(errors.Wrap(err, "error in loopEmit"))
+
+				// Errors caused by the shutdown are not sent to the engine, which may have stopped receiving
+				if ctx.Err() != nil {
+					continue
+				}
+
+				// Transient errors are only sent to the engine once the circuit breaker opens
+				if err := e.breaker.failed(err); err != nil {
+					e.emitError(errors.Wrap(err, "error in loopEmit"))
+				}
+
+				e.breaker.wait(ctx)
 			}
 		}
 	}
@@ -110,3 +153,16 @@ func (e *emitter) SyncsEngine() {
 	<-e.syncChan
 	e.debugger.Debug(1, "synced with engine")
 }
+
+// syncEngine is SyncsEngine that returns an error if |ctx| is done before the engine syncs.
+func (e *emitter) syncEngine(ctx context.Context) error {
+	e.debugger.Debug(1, "waiting for engine sync")
+	select {
+	case <-e.syncChan:
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "syncEngine canceled")
+	}
+
+	e.debugger.Debug(1, "synced with engine")
+	return nil
+}
@@ -28,7 +28,9 @@ func init() {
 }
 
 func TestEmitterV1(t *testing.T) {
-	testutils.RunTestCase(t, "testEmitterV1", testlogs.TestCasesV1, testEmitterV1)
+	if err := testutils.RunTestCase(t, "testEmitterV1", testlogs.TestCasesV1, testEmitterV1); err != nil {
+		t.Fatal(err.Error())
+	}
 }
 
 // testEmitterV1 is designed to test emitter's full `Loop` with ReorgSimV1 mocked chain.
@@ -38,6 +40,7 @@ func testEmitterV1(t *testing.T, caseNumber int) error {
 		superwatcher.PolicyFast,
 		superwatcher.PolicyNormal,
 		superwatcher.PolicyExpensive,
+		superwatcher.PolicyExpensiveBlock,
 	} {
 		tc := testlogs.TestCasesV1[caseNumber-1]
 		b, _ := json.Marshal(tc)
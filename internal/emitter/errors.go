@@ -5,7 +5,9 @@ import (
 )
 
 var (
-	ErrEmitterShutdown   = errors.New("emitter was told to shutdown - Loop context done") // emitter received a shutdown signal
-	ErrMaxRetriesReached = errors.New("emitter has reached max goBackRetries")            // emitter.conf.GoBackRetries has been reached
-	errNoNewBlock        = errors.New("no new block")                                     // No new block after the last recorded block
+	ErrEmitterShutdown      = errors.New("emitter was told to shutdown - Loop context done") // emitter received a shutdown signal
+	ErrMaxRetriesReached    = errors.New("emitter has reached max goBackRetries")            // emitter.conf.GoBackRetries has been reached
+	ErrCircuitOpen          = errors.New("emitter circuit breaker opened")                   // Config.Retry.MaxFailures consecutive loopEmit failures
+	ErrSeekBeforeStartBlock = errors.New("cannot seek before Config.StartBlock")             // SeekTo was called with a block before Config.StartBlock
+	errNoNewBlock           = errors.New("no new block")                                     // No new block after the last recorded block
 )
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/soyart/gsl"
 	"go.uber.org/zap"
 
 	"github.com/soyart/superwatcher"
@@ -16,41 +17,81 @@ type emitterStatus struct {
 	ToBlock           uint64 `json:"toBlock"`
 	CurrentBlock      uint64 `json:"currentBlock"`
 	LastRecordedBlock uint64 `json:"lastRecordedBlock"`
+	FilterRange       uint64 `json:"filterRange"`
+	ConfirmedBlock    uint64 `json:"confirmedBlock"` // Newest block with Config.Confirmations, same as CurrentBlock if there's no confirmations
+
+	// UnconfirmedReorgs counts reorged blocks newer than ConfirmedBlock,
+	// which were detected by the poller but were never delivered.
+	UnconfirmedReorgs uint64 `json:"unconfirmedReorgs"`
 
 	GoBackFirstStart bool   `json:"goBackFirstStart"`
 	IsReorging       bool   `json:"isReorging"`
 	RetriesCount     uint64 `json:"retriesCount"`
 }
 
-func (e *emitter) sleep() {
-	time.Sleep(time.Second * time.Duration(e.conf.LoopInterval))
+// sleep waits for the next loop, either for a new head if e.heads is set, or for e.interval.
+// It returns early if |ctx| is done.
+func (e *emitter) sleep(ctx context.Context, status *emitterStatus) {
+	if e.heads != nil {
+		e.heads.wait(ctx)
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(e.interval(ctx, status)):
+	}
+}
+
+// withTimeout returns |ctx| with a timeout of |seconds|, or a cancelable |ctx| if |seconds| is 0
+func withTimeout(ctx context.Context, seconds uint64) (context.Context, context.CancelFunc) {
+	if seconds == 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Second*time.Duration(seconds))
 }
 
 func (e *emitter) loopEmit(
 	ctx context.Context,
 	status *emitterStatus,
 ) error {
+	// Catch up with the chain head first if the emitter is far behind
+	if e.conf.BackfillWorkers != 0 {
+		if err := e.backfill(ctx); err != nil {
+			return errors.Wrap(err, "backfill failed")
+		}
+	}
+
 	// Assume that this is a normal first start (watcher restarted).
 	status.GoBackFirstStart = true
 
-	// This will keep track of fromBlock/toBlock, as well as reorg status
-	loopCtx := context.Background()
 	for {
 		// Don't sleep or log status on first loop
 		if !status.GoBackFirstStart {
 			e.debugger.Debug(1, "new loopEmit loop", zap.Any("current status", status))
-			e.sleep()
+			e.sleep(ctx, status)
 		}
 
+		e.waitResume(ctx)
+
 		select {
 		// Graceful shutdown in main
 		case <-ctx.Done():
 			return errors.Wrap(ctx.Err(), "exiting loopEmit")
 
 		default:
+			sought, err := e.applySeek(ctx, status)
+			if err != nil {
+				return errors.Wrap(err, "emitter failed to seek")
+			}
+			if sought {
+				continue
+			}
+
 			// Compute current fromBlock and toBlock
 			newStatus, err := e.computeFromBlockToBlock(
-				loopCtx,
+				ctx,
 				status,
 			)
 
@@ -95,11 +136,24 @@ func (e *emitter) loopEmit(
 				zap.Any("current_status", newStatus),
 			)
 
+			// With confirmations, the poller also polls the unconfirmed window after newStatus.ToBlock,
+			// so that chain reorgs there are detected, but the engine only delivers blocks up to newStatus.ToBlock.
+			pollToBlock := newStatus.ToBlock
+			if e.conf.Confirmations != 0 {
+				pollToBlock = gsl.Max(pollToBlock, gsl.Min(newStatus.CurrentBlock, newStatus.ToBlock+e.conf.Confirmations))
+			}
+
+			pollCtx, cancel := withTimeout(ctx, e.conf.PollTimeout)
 			result, err := e.poller.Poll(
-				loopCtx,
+				pollCtx,
 				newStatus.FromBlock,
-				newStatus.ToBlock,
+				pollToBlock,
 			)
+			cancel()
+
+			if result != nil && e.conf.Confirmations != 0 {
+				e.markConfirmed(result, newStatus)
+			}
 			if err != nil {
 				// TODO: Use ErrChainIsReorging
 				if errors.Is(err, superwatcher.ErrChainIsReorging) {
@@ -114,8 +168,15 @@ func (e *emitter) loopEmit(
 						)
 					}
 
-					e.emitFilterResult(result)
-					e.SyncsEngine()
+					// The poller has no result if the chain reorged before it could collect one,
+					// e.g. while it was getting block headers
+					if result != nil {
+						if err := e.emitAndSync(ctx, result); err != nil {
+							return err
+						}
+					}
+
+					e.breaker.succeeded()
 					// Re-poll
 					continue
 				} else {
@@ -131,6 +192,12 @@ func (e *emitter) loopEmit(
 							1, "got ErrFetchError, blockchain node may be the culprit",
 						)
 					}
+					if errors.Is(err, superwatcher.ErrFinalizedBlockChanged) {
+						e.debugger.Debug(
+							1, "got ErrFinalizedBlockChanged, blockchain node or chain finality may be broken",
+							zap.Error(err),
+						)
+					}
 
 					return errors.Wrap(err, "unexpected poller error")
 				}
@@ -144,9 +211,12 @@ func (e *emitter) loopEmit(
 				zap.Uint64("lastGoodBlock", result.LastGoodBlock),
 			)
 
-			e.emitFilterResult(result)
-			e.SyncsEngine()
+			if err := e.emitAndSync(ctx, result); err != nil {
+				return err
+			}
+
 			updateStatus(false)
+			e.breaker.succeeded()
 
 			e.debugger.Debug(
 				3, "ending this loop",
@@ -169,7 +239,10 @@ func (e *emitter) computeFromBlockToBlock(
 ) {
 	// lastRecordedBlock was saved externally by engine.
 	// The value to be saved should be superwatcher.PollerResult.LastGoodBlock
-	lastRecordedBlock, err := e.stateDataGateway.GetLastRecordedBlock(ctx)
+	callCtx, cancel := withTimeout(ctx, e.conf.CallTimeout)
+	lastRecordedBlock, err := e.stateDataGateway.GetLastRecordedBlock(callCtx)
+	cancel()
+
 	if err != nil {
 		// Return error if not superwatcher.ErrRecordNotFound
 		if !errors.Is(err, superwatcher.ErrRecordNotFound) {
@@ -184,7 +257,14 @@ func (e *emitter) computeFromBlockToBlock(
 	}
 
 	// Get chain's tallest block number and compare it with lastRecordedBlock
-	currentBlock, err := e.client.BlockNumber(ctx)
+	var currentBlock uint64
+	err = e.conf.Retry.Do(ctx, func() error {
+		callCtx, cancel := withTimeout(ctx, e.conf.CallTimeout)
+		defer cancel()
+
+		currentBlock, err = e.client.BlockNumber(callCtx)
+		return err
+	})
 	if err != nil {
 		return prevStatus, errors.Wrap(err, "failed to get current block number from node")
 	}
@@ -195,23 +275,30 @@ func (e *emitter) computeFromBlockToBlock(
 		zap.Uint64("lastRecordedBlock", lastRecordedBlock),
 	)
 
+	// Blocks newer than confirmedBlock do not have enough confirmations yet
+	confirmedBlock := confirmedBlock(currentBlock, e.conf.Confirmations)
+
 	// Return now if there's no new block
-	if lastRecordedBlock == currentBlock {
+	if lastRecordedBlock == confirmedBlock || (e.conf.Confirmations != 0 && lastRecordedBlock > confirmedBlock) {
 		if !prevStatus.GoBackFirstStart {
-			return prevStatus, errors.Wrapf(errNoNewBlock, "block %d", currentBlock)
+			return prevStatus, errors.Wrapf(errNoNewBlock, "block %d", confirmedBlock)
 		}
 	}
 
 	// Update prevStatus with current states
 	prevStatus.CurrentBlock = currentBlock
+	prevStatus.ConfirmedBlock = confirmedBlock
 	prevStatus.LastRecordedBlock = lastRecordedBlock
 
+	filterRange := e.filterRange()
+
 	// And send the updated states to computeFromBlockToBlock
 	fromBlock, toBlock, err := computeFromBlockToBlock(
 		prevStatus,
 		currentBlock,
+		e.conf.Confirmations,
 		lastRecordedBlock,
-		e.conf.FilterRange,
+		filterRange,
 		e.conf.MaxGoBackRetries,
 		e.conf.StartBlock,
 		e.poller.DoReorg(),
@@ -233,11 +320,33 @@ func (e *emitter) computeFromBlockToBlock(
 		ToBlock:           toBlock,
 		CurrentBlock:      currentBlock,
 		LastRecordedBlock: lastRecordedBlock,
+		FilterRange:       filterRange,
+		ConfirmedBlock:    confirmedBlock,
+		UnconfirmedReorgs: prevStatus.UnconfirmedReorgs,
 		IsReorging:        prevStatus.IsReorging,
 		RetriesCount:      prevStatus.RetriesCount,
 	}, nil
 }
 
+// filterRange returns the filter range to use for the next poll. It is e.conf.FilterRange,
+// unless the poller has learned that the node cannot handle FilterLogs calls that large,
+// in which case the poller's range limit is used instead. This means that the filter range
+// shrinks when the node rejects large ranges, and grows back as the poller's limit grows.
+func (e *emitter) filterRange() uint64 {
+	filterRange := e.conf.FilterRange
+	if limit := e.poller.FilterRangeLimit(); limit != 0 && limit < filterRange {
+		e.debugger.Debug(
+			2, "using poller filter range limit",
+			zap.Uint64("configFilterRange", filterRange),
+			zap.Uint64("filterRangeLimit", limit),
+		)
+
+		filterRange = limit
+	}
+
+	return filterRange
+}
+
 // computeFromBlockToBlock defines a more higher-level computation logic for getting fromBlock and toBlock.
 // It takes into account the emitter's config, the emitter status, chain status, etc.
 // There are 3 possible cases when computing fromBlock and toBlock:
@@ -250,6 +359,7 @@ func (e *emitter) computeFromBlockToBlock(
 func computeFromBlockToBlock(
 	prevStatus *emitterStatus,
 	currentBlock uint64,
+	confirmations uint64,
 	lastRecordedBlock uint64,
 	filterRange uint64,
 	maxRetries uint64,
@@ -264,6 +374,11 @@ func computeFromBlockToBlock(
 	var err error
 	var fromBlock, toBlock, goBack uint64
 
+	// With confirmations, the computed range must not go beyond the newest confirmed block
+	if confirmations != 0 {
+		currentBlock = confirmedBlock(currentBlock, confirmations)
+	}
+
 	switch {
 	case prevStatus.GoBackFirstStart:
 
@@ -336,9 +451,43 @@ func computeFromBlockToBlock(
 		)
 	}
 
+	// fromBlockToBlockGoBackFirstRun does not use currentBlock, so we cap toBlock here
+	if confirmations != 0 && toBlock > currentBlock {
+		toBlock = currentBlock
+		fromBlock = gsl.Min(fromBlock, toBlock)
+	}
+
 	// Update status here too
 	prevStatus.FromBlock = fromBlock
 	prevStatus.ToBlock = toBlock
 
 	return fromBlock, toBlock, err
 }
+
+// markConfirmed sets result.ConfirmedBlock to status.ToBlock, so that the engine only delivers blocks
+// with enough confirmations. Reorged blocks in the unconfirmed window are counted in status.UnconfirmedReorgs.
+func (e *emitter) markConfirmed(
+	result *superwatcher.PollerResult,
+	status *emitterStatus,
+) {
+	result.ConfirmedBlock = status.ToBlock
+
+	var unconfirmedReorgs []uint64
+	for _, b := range result.ReorgedBlocks {
+		if b.Number > status.ToBlock {
+			unconfirmedReorgs = append(unconfirmedReorgs, b.Number)
+		}
+	}
+
+	if len(unconfirmedReorgs) == 0 {
+		return
+	}
+
+	status.UnconfirmedReorgs += uint64(len(unconfirmedReorgs))
+	e.debugger.Debug(
+		1, "chain reorg detected in unconfirmed window",
+		zap.Uint64("confirmedBlock", status.ToBlock),
+		zap.Uint64s("reorgedBlocks", unconfirmedReorgs),
+		zap.Uint64("unconfirmedReorgs", status.UnconfirmedReorgs),
+	)
+}
@@ -1,8 +1,13 @@
 package emitter
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
 	"github.com/soyart/superwatcher/pkg/logger/debugger"
 )
 
@@ -24,6 +29,7 @@ func TestComputeFromBlockToBlock(t *testing.T) {
 		Name               string `json:"Name"`
 		StartBlock         uint64 `json:"startBlock"`
 		CurrentBlock       uint64 `json:"currentBlock"`
+		Confirmations      uint64 `json:"confirmations"`
 		LastRecordedBlocks uint64 `json:"lastRecordedBlocks"`
 		FilterRange        uint64 `json:"filterRange"`
 		RetriesCount       uint64 `json:"maxRetries"`
@@ -163,6 +169,41 @@ func TestComputeFromBlockToBlock(t *testing.T) {
 			fromBlock: 13000, // (Base+1) - GoBack
 			toBlock:   14999, // fromBlock + FilterRange
 		},
+		{
+			Name:               "confirmations_100",
+			CurrentBlock:       200,
+			Confirmations:      10,
+			LastRecordedBlocks: 100, // Base
+			FilterRange:        10,  // FilterRange
+			MaxRetries:         2,
+		}: {
+			fromBlock: 91,  // (Base+1) - FilterRange
+			toBlock:   110, // fromBlock + FilterRange
+		},
+		{
+			Name:               "confirmations_185",
+			CurrentBlock:       200,
+			Confirmations:      10,
+			LastRecordedBlocks: 185, // Base
+			FilterRange:        10,  // FilterRange
+			MaxRetries:         2,
+		}: {
+			fromBlock: 176, // (Base+1) - FilterRange
+			toBlock:   190, // CurrentBlock - Confirmations
+		},
+		{
+			Name:               "confirmations_first_14999",
+			CurrentBlock:       15000,
+			Confirmations:      100,
+			LastRecordedBlocks: 14999, // Base
+			FilterRange:        2000,
+			RetriesCount:       1,
+			MaxRetries:         2,
+			GoBackFirstStart:   firstStart,
+		}: {
+			fromBlock: 13000, // (Base+1) - GoBack
+			toBlock:   14900, // CurrentBlock - Confirmations
+		},
 	}
 
 	for test, expected := range tests {
@@ -177,6 +218,7 @@ func TestComputeFromBlockToBlock(t *testing.T) {
 				test.RetriesCount,
 			),
 			test.CurrentBlock,
+			test.Confirmations,
 			test.LastRecordedBlocks,
 			test.FilterRange,
 			test.MaxRetries,
@@ -204,3 +246,86 @@ func TestComputeFromBlockToBlock(t *testing.T) {
 		}
 	}
 }
+
+// hangingClient is a node whose BlockNumber calls hang until canceled
+type hangingClient struct {
+	superwatcher.EthClient
+}
+
+func (hangingClient) BlockNumber(ctx context.Context) (uint64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+// resultPoller returns an empty result for every Poll call
+type resultPoller struct {
+	superwatcher.EmitterPoller
+}
+
+func (resultPoller) Poll(_ context.Context, fromBlock, toBlock uint64) (*superwatcher.PollerResult, error) {
+	return &superwatcher.PollerResult{FromBlock: fromBlock, ToBlock: toBlock, LastGoodBlock: toBlock}, nil
+}
+
+func (resultPoller) DoReorg() bool            { return false }
+func (resultPoller) FilterRangeLimit() uint64 { return 0 }
+
+// TestLoopShutdown checks that canceling Loop's context aborts hanging node calls,
+// and sends to an engine that no longer receives results.
+func TestLoopShutdown(t *testing.T) {
+	stateDataGateway := superwatcher.GetStateDataGatewayFunc(func(context.Context) (uint64, error) {
+		return 0, superwatcher.ErrRecordNotFound
+	})
+
+	clients := map[string]superwatcher.EthClient{
+		"hanging node":   hangingClient{},
+		"stopped engine": &blockNumberClient{currentBlock: 100},
+	}
+
+	for name, client := range clients {
+		conf := &superwatcher.Config{StartBlock: 1, FilterRange: 10, LoopInterval: 60}
+		pollResultChan := make(chan *superwatcher.PollerResult)
+		e := New(conf, client, stateDataGateway, resultPoller{}, make(chan struct{}), pollResultChan, make(chan error))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- e.Loop(ctx)
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-errChan:
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("[%s] expecting context.Canceled, got %v", name, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("[%s] Loop did not return after cancel", name)
+		}
+	}
+}
+
+// TestCallTimeout checks that node calls hanging longer than Config.CallTimeout fail loopEmit.
+func TestCallTimeout(t *testing.T) {
+	conf := &superwatcher.Config{StartBlock: 1, FilterRange: 10, CallTimeout: 1}
+	stateDataGateway := superwatcher.GetStateDataGatewayFunc(func(context.Context) (uint64, error) {
+		return 0, superwatcher.ErrRecordNotFound
+	})
+
+	e := New(conf, hangingClient{}, stateDataGateway, resultPoller{}, nil, nil, nil).(*emitter)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- e.loopEmit(context.Background(), new(emitterStatus))
+	}()
+
+	select {
+	case err := <-errChan:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expecting context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("loopEmit did not time out")
+	}
+}
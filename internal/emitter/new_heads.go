@@ -0,0 +1,123 @@
+package emitter
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// subscribedWaitFactor is the number of loop intervals newHeads.wait waits for a new head while subscribed,
+// so that loopEmit still polls if the subscription stops sending heads without dropping
+const subscribedWaitFactor = 3
+
+// newHeads wakes loopEmit when the node sends new heads via `eth_subscribe("newHeads")`.
+// While there's no active subscription, loopEmit falls back to sleeping for the loop interval.
+type newHeads struct {
+	client   superwatcher.EthClient
+	interval time.Duration // Config.LoopInterval, also used as delay between resubscribe attempts
+
+	subscribed atomic.Bool
+	wake       chan struct{} // buffered, so that heads arriving during a poll are coalesced into 1 wake up
+	debugger   *debugger.Debugger
+}
+
+func newNewHeads(client superwatcher.EthClient, interval time.Duration, logLevel uint8) *newHeads {
+	return &newHeads{
+		client:   client,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		debugger: debugger.NewDebugger("emitter newHeads", logLevel),
+	}
+}
+
+// loop keeps a newHeads subscription alive until |ctx| is done, resubscribing after the subscription drops.
+func (h *newHeads) loop(ctx context.Context) {
+	for {
+		if err := h.subscribe(ctx); err != nil {
+			h.debugger.Warn(1, "newHeads subscription failed, falling back to loop interval", zap.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.resubscribeDelay()):
+		}
+	}
+}
+
+// subscribe subscribes to new heads, and signals h.wake for every new head until the subscription drops.
+// It wakes loopEmit once more when the subscription drops, so that loopEmit goes back to the loop interval.
+func (h *newHeads) subscribe(ctx context.Context) error {
+	heads := make(chan superwatcher.BlockHeader)
+	sub, err := h.client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return err
+	}
+
+	defer sub.Unsubscribe()
+
+	h.subscribed.Store(true)
+	defer func() {
+		h.subscribed.Store(false)
+		h.signal()
+	}()
+
+	h.debugger.Debug(1, "subscribed to newHeads")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-sub.Err():
+			return err
+
+		case head := <-heads:
+			h.debugger.Debug(3, "got new head", zap.Uint64("blockNumber", head.Number()))
+			h.signal()
+		}
+	}
+}
+
+// signal wakes loopEmit without blocking
+func (h *newHeads) signal() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// wait blocks until a new head arrives or |ctx| is done. Without an active subscription, it only waits up to h.interval.
+// With an active subscription, it waits up to subscribedWaitFactor intervals, in case the subscription silently stalls.
+func (h *newHeads) wait(ctx context.Context) {
+	select {
+	case <-h.wake:
+	case <-ctx.Done():
+	case <-time.After(h.maxWait()):
+	}
+}
+
+// maxWait returns how long wait blocks without new heads
+func (h *newHeads) maxWait() time.Duration {
+	if !h.subscribed.Load() {
+		return h.interval
+	}
+
+	if h.interval == 0 {
+		return subscribedWaitFactor * h.resubscribeDelay()
+	}
+
+	return subscribedWaitFactor * h.interval
+}
+
+func (h *newHeads) resubscribeDelay() time.Duration {
+	if h.interval < time.Second {
+		return time.Second
+	}
+
+	return h.interval
+}
@@ -0,0 +1,115 @@
+package emitter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// TestNewHeads checks that newHeads wakes on every new head while subscribed,
+// and falls back to the loop interval once the subscription drops.
+func TestNewHeads(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	param := tc.Param
+	param.HeadInterval = 10 * time.Millisecond
+	param.HeadsBeforeDrop = 3
+
+	events := []reorgsim.ReorgEvent{{ReorgBlock: tc.ToBlock + 1000}}
+	chain, reorgedChains := reorgsim.NewBlockChain(reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...), events)
+	sim, err := reorgsim.NewReorgSim(param, events, chain, reorgedChains, "", 1)
+	if err != nil {
+		t.Fatal("cannot init ReorgSim", err.Error())
+	}
+
+	interval := 50 * time.Millisecond
+	heads := newNewHeads(sim, interval, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go heads.loop(ctx)
+
+	waitFor := func(cond func() bool) {
+		t.Helper()
+
+		deadline := time.Now().Add(time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitFor(heads.subscribed.Load)
+
+	woke := make(chan struct{})
+	go func() {
+		heads.wait(context.Background())
+		close(woke)
+	}()
+
+	select {
+	case <-woke:
+	case <-time.After(time.Second):
+		t.Fatal("wait did not return on new head")
+	}
+
+	// After the subscription drops, wait only blocks for the loop interval
+	waitFor(func() bool { return !heads.subscribed.Load() })
+
+	// Drain the wake up sent when the subscription dropped
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-heads.wake:
+	default:
+	}
+
+	start := time.Now()
+	heads.wait(context.Background())
+	if elapsed := time.Since(start); elapsed < interval || elapsed > 10*interval {
+		t.Fatalf("expecting fallback wait of about %s, waited %s", interval, elapsed)
+	}
+}
+
+// stalledClient subscribes to new heads, but never sends any head or error
+type stalledClient struct {
+	superwatcher.EthClient
+}
+
+func (stalledClient) SubscribeNewHead(context.Context, chan<- superwatcher.BlockHeader) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	}), nil
+}
+
+// TestNewHeadsStalled checks that wait is still bounded by the loop interval while subscribed
+func TestNewHeadsStalled(t *testing.T) {
+	interval := 20 * time.Millisecond
+	heads := newNewHeads(stalledClient{}, interval, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go heads.loop(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for !heads.subscribed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	heads.wait(context.Background())
+	if elapsed := time.Since(start); elapsed < subscribedWaitFactor*interval || elapsed > 10*subscribedWaitFactor*interval {
+		t.Fatalf("expecting subscribed wait of about %s, waited %s", subscribedWaitFactor*interval, elapsed)
+	}
+}
@@ -24,6 +24,7 @@ type metadataTracker interface {
 	ClearUntil(blockNumber uint64)
 	SetBlockMetadata(callerMethod, *blockMetadata)
 	GetBlockMetadata(callerMethod, uint64, string) *blockMetadata
+	RemoveBlockMetadata(callerMethod, *blockMetadata)
 }
 
 // metadataTrackerImpl is an in-memory store for keeping engine internal states.
@@ -119,6 +120,23 @@ func (t *metadataTrackerImpl) GetBlockMetadata(
 	return meta
 }
 
+func (t *metadataTrackerImpl) RemoveBlockMetadata(
+	caller callerMethod,
+	metadata *blockMetadata,
+) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.debugger.Debug(
+		3, "removing blockMetadata",
+		zap.String("caller", string(caller)),
+		zap.Uint64("blockNumber", metadata.blockNumber),
+		zap.String("blockHash", metadata.blockHash),
+	)
+
+	t.sortedSet.Remove(metadata.blockHash)
+}
+
 func (t *metadataTrackerImpl) Len() int {
 	t.RLock()
 	defer t.RUnlock()
@@ -30,13 +30,23 @@ func (e *engine) handleResults(ctx context.Context) error {
 			return nil
 		}
 
+		// Only deliver blocks with enough confirmations
+		result = superwatcher.ConfirmedResult(result)
+
 		var reorged bool
 		var shouldCallServiceEngine bool
 
 		var reorgedBlocks engineBlocks
 		for _, block := range result.ReorgedBlocks {
 			reorged = true
-			metadata := e.metadataTracker.GetBlockMetadata(callerReorgedLogs, block.Number, block.String())
+
+			var metadata *blockMetadata
+			if result.Rewind {
+				metadata = e.rewoundMetadata(block)
+			} else {
+				metadata = e.metadataTracker.GetBlockMetadata(callerReorgedLogs, block.Number, block.String())
+			}
+
 			e.debugger.Debug(
 				3, "* got reorged metadata",
 				zap.Uint64("blockNumber", block.Number),
@@ -95,6 +105,11 @@ func (e *engine) handleResults(ctx context.Context) error {
 				zap.Any("metadata artifacts", metadata.artifacts),
 			)
 			e.metadataTracker.SetBlockMetadata(callerReorgedLogs, metadata)
+
+			// Rewound blocks will be emitted again with the same hashes, and must be handled as new blocks
+			if result.Rewind {
+				e.metadataTracker.RemoveBlockMetadata(callerReorgedLogs, metadata)
+			}
 		}
 
 		var goodBlocks engineBlocks
@@ -166,3 +181,14 @@ func (e *engine) handleResults(ctx context.Context) error {
 		e.emitterClient.SyncsEmitter()
 	}
 }
+
+// rewoundMetadata returns metadata of |block| rewound by the emitter. Rewound blocks were recorded before,
+// so a block no longer tracked by the engine is considered handled.
+func (e *engine) rewoundMetadata(block *superwatcher.Block) *blockMetadata {
+	metadata := e.metadataTracker.GetBlockMetadata(callerGoodLogs, block.Number, block.String())
+	if metadata.state == stateNull {
+		metadata.state = stateHandled
+	}
+
+	return metadata
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/soyart/superwatcher"
+)
+
+// resultsClient is an EmitterClient that sends |results| to the engine, and then nil
+type resultsClient struct {
+	superwatcher.EmitterClient
+	results []*superwatcher.PollerResult
+}
+
+func (c *resultsClient) WatcherResult() *superwatcher.PollerResult {
+	if len(c.results) == 0 {
+		return nil
+	}
+
+	result := c.results[0]
+	c.results = c.results[1:]
+
+	return result
+}
+
+func (c *resultsClient) WatcherConfig() *superwatcher.Config {
+	return &superwatcher.Config{FilterRange: 10, MaxGoBackRetries: 2}
+}
+
+func (c *resultsClient) SyncsEmitter() {}
+
+// blockNumbersService records the block numbers of each ServiceEngine call
+type blockNumbersService struct {
+	superwatcher.ServiceEngine
+	good    [][]uint64
+	reorged [][]uint64
+}
+
+func blockNumbers(blocks []*superwatcher.Block) []uint64 {
+	numbers := make([]uint64, len(blocks))
+	for i, b := range blocks {
+		numbers[i] = b.Number
+	}
+
+	return numbers
+}
+
+func (s *blockNumbersService) HandleGoodBlocks(blocks []*superwatcher.Block, _ []superwatcher.Artifact) (map[common.Hash][]superwatcher.Artifact, error) {
+	s.good = append(s.good, blockNumbers(blocks))
+	return nil, nil
+}
+
+func (s *blockNumbersService) HandleReorgedBlocks(blocks []*superwatcher.Block, _ []superwatcher.Artifact) (map[common.Hash][]superwatcher.Artifact, error) {
+	s.reorged = append(s.reorged, blockNumbers(blocks))
+	return nil, nil
+}
+
+// TestHandleRewind checks that rewound blocks are undone, including blocks the engine no longer tracks,
+// and are handled again when emitted with the same hashes.
+func TestHandleRewind(t *testing.T) {
+	client := &resultsClient{
+		results: []*superwatcher.PollerResult{
+			{FromBlock: 11, ToBlock: 13, LastGoodBlock: 13, GoodBlocks: []*superwatcher.Block{newBlock(11), newBlock(12), newBlock(13)}},
+			{FromBlock: 10, ToBlock: 13, LastGoodBlock: 9, ReorgedBlocks: []*superwatcher.Block{newBlock(10), newBlock(12), newBlock(13)}, Rewind: true},
+			{FromBlock: 10, ToBlock: 13, LastGoodBlock: 13, GoodBlocks: []*superwatcher.Block{newBlock(10), newBlock(11), newBlock(12), newBlock(13)}},
+		},
+	}
+
+	var lastRecordedBlocks []uint64
+	stateDataGateway := superwatcher.SetStateDataGatewayFunc(func(_ context.Context, n uint64) error {
+		lastRecordedBlocks = append(lastRecordedBlocks, n)
+		return nil
+	})
+
+	service := new(blockNumbersService)
+	e := New(client, service, stateDataGateway, 1).(*engine)
+	if err := e.handleResults(context.Background()); err != nil {
+		t.Fatal("unexpected handleResults error", err.Error())
+	}
+
+	expectedGood := [][]uint64{{11, 12, 13}, {10, 12, 13}}
+	expectedReorged := [][]uint64{{10, 12, 13}}
+	if len(service.good) != len(expectedGood) || len(service.reorged) != len(expectedReorged) {
+		t.Fatalf("unexpected service calls: good %v, reorged %v", service.good, service.reorged)
+	}
+
+	for i, expected := range expectedGood {
+		for j, n := range expected {
+			if service.good[i][j] != n {
+				t.Fatalf("unexpected good blocks %v, expecting %v", service.good[i], expected)
+			}
+		}
+	}
+
+	for j, n := range expectedReorged[0] {
+		if service.reorged[0][j] != n {
+			t.Fatalf("unexpected reorged blocks %v, expecting %v", service.reorged[0], expectedReorged[0])
+		}
+	}
+
+	if lastRecordedBlocks[1] != 9 {
+		t.Fatalf("expecting last recorded block 9 after rewind, got %d", lastRecordedBlocks[1])
+	}
+}
@@ -0,0 +1,151 @@
+package poller
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// maxAddressChunkWorkers is the max number of address chunks queried concurrently
+const maxAddressChunkWorkers = 8
+
+// SetAddressChunkSize sets the max number of addresses per FilterLogs query. 0 means no limit.
+func (p *poller) SetAddressChunkSize(size uint64) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.addressChunkSize = size
+}
+
+// withAddressChunks returns |client| wrapped with addressChunksClient, or |client| if |chunkSize| is 0.
+func withAddressChunks(
+	client superwatcher.EthClient,
+	chunkSize uint64,
+	debugger *debugger.Debugger,
+) superwatcher.EthClient {
+	if chunkSize == 0 {
+		return client
+	}
+
+	return &addressChunksClient{
+		EthClient: client,
+		chunkSize: chunkSize,
+		debugger:  debugger,
+	}
+}
+
+// addressChunksClient wraps superwatcher.EthClient, and splits FilterLogs calls with more than chunkSize addresses
+// into queries of at most chunkSize addresses each, for the same block range (or block hash). The queries are sent
+// concurrently, and their logs are merged, de-duplicated, and ordered by block number, transaction index and log index,
+// so the caller sees 1 big FilterLogs call. Logs from different chunks with different block hashes are all kept,
+// so that collectLogs can still detect inconsistent hashes across chunks.
+type addressChunksClient struct {
+	superwatcher.EthClient
+
+	chunkSize uint64
+	debugger  *debugger.Debugger
+}
+
+func (c *addressChunksClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if uint64(len(q.Addresses)) <= c.chunkSize {
+		return c.EthClient.FilterLogs(ctx, q)
+	}
+
+	chunks := addressChunks(q.Addresses, c.chunkSize)
+
+	c.debugger.Debug(
+		3, "splitting filterLogs addresses",
+		zap.Int("addresses", len(q.Addresses)),
+		zap.Int("chunks", len(chunks)),
+	)
+
+	results := make([][]types.Log, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, maxAddressChunkWorkers)
+
+	var wg sync.WaitGroup
+	for i := range chunks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			chunkQuery := q
+			chunkQuery.Addresses = chunks[i]
+			results[i], errs[i] = c.EthClient.FilterLogs(ctx, chunkQuery)
+		}(i)
+	}
+
+	wg.Wait()
+
+	var logs []types.Log
+	for i := range chunks {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		logs = append(logs, results[i]...)
+	}
+
+	return mergeChunkLogs(logs), nil
+}
+
+// addressChunks splits |addresses| into chunks of at most |chunkSize| addresses
+func addressChunks(addresses []common.Address, chunkSize uint64) [][]common.Address {
+	var chunks [][]common.Address
+	for start := uint64(0); start < uint64(len(addresses)); start += chunkSize {
+		end := start + chunkSize
+		if end > uint64(len(addresses)) {
+			end = uint64(len(addresses))
+		}
+
+		chunks = append(chunks, addresses[start:end])
+	}
+
+	return chunks
+}
+
+// mergeChunkLogs de-duplicates |logs| by block hash and log index,
+// and sorts them by block number, transaction index and log index.
+func mergeChunkLogs(logs []types.Log) []types.Log {
+	type logKey struct {
+		blockHash common.Hash
+		index     uint
+	}
+
+	seen := make(map[logKey]bool, len(logs))
+	merged := make([]types.Log, 0, len(logs))
+	for i := range logs {
+		key := logKey{blockHash: logs[i].BlockHash, index: logs[i].Index}
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		merged = append(merged, logs[i])
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := &merged[i], &merged[j]
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		if a.TxIndex != b.TxIndex {
+			return a.TxIndex < b.TxIndex
+		}
+
+		return a.Index < b.Index
+	})
+
+	return merged
+}
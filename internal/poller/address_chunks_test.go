@@ -0,0 +1,158 @@
+package poller
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// chunkCountingClient records the number of addresses of each FilterLogs query
+type chunkCountingClient struct {
+	superwatcher.EthClient
+
+	sync.Mutex
+	queries []int
+}
+
+func (c *chunkCountingClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.Lock()
+	c.queries = append(c.queries, len(q.Addresses))
+	c.Unlock()
+
+	return c.EthClient.FilterLogs(ctx, q)
+}
+
+func TestAddressChunks(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	seen := make(map[common.Address]bool)
+	var addresses []common.Address
+	for _, blockLogs := range logs {
+		for _, log := range blockLogs {
+			if !seen[log.Address] {
+				seen[log.Address] = true
+				addresses = append(addresses, log.Address)
+			}
+		}
+	}
+
+	// Duplicate addresses in different chunks must not duplicate logs
+	addresses = append(addresses, addresses[0])
+
+	poll := func(chunkSize uint64) (*superwatcher.PollerResult, *chunkCountingClient) {
+		sim := newTestSim(t, tc.Param, logs)
+
+		client := &chunkCountingClient{EthClient: sim}
+		p := New(addresses, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+		p.SetAddressChunkSize(chunkSize)
+
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatalf("unexpected poll error with chunk size %d: %s", chunkSize, err.Error())
+		}
+
+		return result, client
+	}
+
+	expected, client := poll(0)
+	if len(client.queries) != 1 || client.queries[0] != len(addresses) {
+		t.Fatalf("expecting 1 query with all addresses without chunks, got %v", client.queries)
+	}
+
+	actual, client := poll(1)
+	if len(client.queries) != len(addresses) {
+		t.Fatalf("expecting %d queries with chunk size 1, got %v", len(addresses), client.queries)
+	}
+
+	for _, n := range client.queries {
+		if n != 1 {
+			t.Fatalf("query with %d addresses exceeds chunk size 1", n)
+		}
+	}
+
+	if len(actual.GoodBlocks) != len(expected.GoodBlocks) {
+		t.Fatalf("expecting %d good blocks, got %d", len(expected.GoodBlocks), len(actual.GoodBlocks))
+	}
+
+	for i, b := range expected.GoodBlocks {
+		actualBlock := actual.GoodBlocks[i]
+		if actualBlock.Hash != b.Hash || len(actualBlock.Logs) != len(b.Logs) {
+			t.Fatalf("unexpected block %d with %d logs, expecting %d logs", b.Number, len(actualBlock.Logs), len(b.Logs))
+		}
+
+		expectedLogs := make(map[uint]common.Hash)
+		for _, log := range b.Logs {
+			expectedLogs[log.Index] = log.TxHash
+		}
+
+		for j, log := range actualBlock.Logs {
+			if txHash, ok := expectedLogs[log.Index]; !ok || txHash != log.TxHash {
+				t.Fatalf("unexpected log %d in block %d", log.Index, b.Number)
+			}
+
+			// Merged logs are ordered by transaction index and log index
+			if j != 0 {
+				prev := actualBlock.Logs[j-1]
+				if prev.TxIndex > log.TxIndex || (prev.TxIndex == log.TxIndex && prev.Index >= log.Index) {
+					t.Fatalf("unexpected log order in block %d", b.Number)
+				}
+			}
+		}
+	}
+}
+
+// forkedChunkClient returns a log from block 1 with a different block hash for each address
+type forkedChunkClient struct {
+	superwatcher.EthClient
+}
+
+func (forkedChunkClient) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for i, address := range q.Addresses {
+		logs = append(logs, types.Log{
+			Address:     address,
+			BlockNumber: 1,
+			BlockHash:   common.BigToHash(address.Big()),
+			Index:       uint(i),
+		})
+	}
+
+	return logs, nil
+}
+
+// TestAddressChunksHashesDiffer checks that logs from chunks seeing different hashes of the same block
+// are all kept, so that collectLogs still detects the inconsistency.
+func TestAddressChunksHashesDiffer(t *testing.T) {
+	addresses := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	client := withAddressChunks(forkedChunkClient{}, 1, debugger.NewDebugger("TestAddressChunksHashesDiffer", 1))
+
+	logs, err := client.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(1),
+		Addresses: addresses,
+	})
+	if err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	if len(logs) != 2 {
+		t.Fatalf("expecting 2 logs from 2 chunks, got %d", len(logs))
+	}
+
+	_, err = collectLogs(make(map[uint64]*mapLogsResult), logs)
+	if !errors.Is(err, errHashesDiffer) {
+		t.Fatalf("expecting errHashesDiffer, got %v", err)
+	}
+}
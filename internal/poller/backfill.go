@@ -0,0 +1,126 @@
+package poller
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// Backfill polls logs from |fromBlock| to |toBlock| like Poll, but without tracking blocks for chain reorg detection,
+// so the result never has ReorgedBlocks. Backfill only reads poller configuration, so it can be called concurrently
+// with other Backfill calls, e.g. by emitter backfill workers. Backfill is meant for deep history, where chain reorgs
+// are no longer possible. Because chunks are polled out of order, addresses discovered in a chunk would be missing
+// from newer chunks already polled, so Backfill returns superwatcher.ErrBadBackfill if the poller has a DiscoveryFunc.
+func (p *poller) Backfill(
+	ctx context.Context,
+	fromBlock uint64,
+	toBlock uint64,
+) (
+	*superwatcher.PollerResult,
+	error,
+) {
+	p.RLock()
+	if p.discovery != nil {
+		p.RUnlock()
+		return nil, superwatcher.ErrBadBackfill
+	}
+
+	b := p.newBackfiller(fromBlock, toBlock)
+	p.RUnlock()
+
+	return b.backfill(ctx)
+}
+
+// backfiller holds a copy of poller configuration used to backfill a range,
+// so that the range can be polled without holding the poller lock.
+type backfiller struct {
+	addresses        []common.Address
+	topics           [][]common.Hash
+	subscriptions    []superwatcher.Subscription
+	rangeLimit       uint64
+	addressChunkSize uint64
+	doReceipts       bool
+	client           superwatcher.EthClient
+	param            *param
+	debugger         *debugger.Debugger
+}
+
+// newBackfiller returns backfiller for range [fromBlock, toBlock]. Must be called with p locked.
+func (p *poller) newBackfiller(fromBlock, toBlock uint64) *backfiller {
+	return &backfiller{
+		addresses:        p.addresses,
+		topics:           p.topics,
+		subscriptions:    p.querySubscriptions(),
+		rangeLimit:       p.rangeLimit,
+		addressChunkSize: p.addressChunkSize,
+		doReceipts:       p.doReceipts,
+		client:           withRetry(p.client, p.retryPolicy, p.debugger),
+		param: &param{
+			fromBlock: fromBlock,
+			toBlock:   toBlock,
+			policy:    p.policy,
+
+			verifyBlockHash: p.doVerifyBlockHash,
+			predicates:      p.predicates,
+		},
+		debugger: p.debugger,
+	}
+}
+
+// backfill polls b.param range without tracker, and collects all polled blocks as GoodBlocks.
+func (b *backfiller) backfill(ctx context.Context) (*superwatcher.PollerResult, error) {
+	var pollClient superwatcher.EthClient = &filterLogsSplitter{
+		EthClient:  withAddressChunks(b.client, b.addressChunkSize, b.debugger),
+		rangeLimit: b.rangeLimit,
+		debugger:   b.debugger,
+	}
+
+	var subsClient *subscriptionsClient
+	if b.subscriptions != nil {
+		subsClient = &subscriptionsClient{
+			EthClient:     pollClient,
+			subscriptions: b.subscriptions,
+			debugger:      b.debugger,
+		}
+		pollClient = subsClient
+	}
+
+	pollResults, err := poll(ctx, b.param, b.addresses, b.topics, pollClient, b.debugger)
+	if err != nil {
+		return nil, err
+	}
+
+	if subsClient != nil {
+		tagLogs(pollResults, subsClient.tags)
+	}
+
+	// Without tracker, processResult only collects pollResults into GoodBlocks
+	result, err := processResult(b.param, nil, pollResults, b.debugger)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.doReceipts {
+		if err := pollReceipts(ctx, b.client, nil, result, b.debugger); err != nil {
+			return nil, errors.Wrap(err, "pollReceipts error")
+		}
+	}
+
+	fromBlock, toBlock := b.param.fromBlock, b.param.toBlock
+	result.FromBlock, result.ToBlock = fromBlock, toBlock
+	result.LastGoodBlock = toBlock
+
+	b.debugger.Debug(
+		2, "backfilled",
+		zap.Uint64("fromBlock", fromBlock),
+		zap.Uint64("toBlock", toBlock),
+		zap.Int("goodBlocks", len(result.GoodBlocks)),
+	)
+
+	return result, nil
+}
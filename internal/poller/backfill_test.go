@@ -0,0 +1,83 @@
+package poller
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// TestBackfill checks that concurrent Backfill calls over chunks of a range
+// return all blocks with logs in the range, without touching the tracker.
+func TestBackfill(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	client := newTestSim(t, tc.Param, logs, reorgsim.ReorgEvent{ReorgBlock: tc.ToBlock + 1000})
+
+	p := New(nil, nil, true, false, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+
+	var mut sync.Mutex
+	var wg sync.WaitGroup
+	blocks := make(map[uint64]*superwatcher.Block)
+	for from := tc.FromBlock; from <= tc.ToBlock; from += 10 {
+		to := from + 9
+		if to > tc.ToBlock {
+			to = tc.ToBlock
+		}
+
+		wg.Add(1)
+		go func(from, to uint64) {
+			defer wg.Done()
+
+			result, err := p.Backfill(context.Background(), from, to)
+			if err != nil {
+				t.Errorf("unexpected Backfill error for %d-%d: %s", from, to, err.Error())
+				return
+			}
+
+			if result.LastGoodBlock != to || len(result.ReorgedBlocks) != 0 {
+				t.Errorf("unexpected result for %d-%d: lastGoodBlock %d, reorgedBlocks %d", from, to, result.LastGoodBlock, len(result.ReorgedBlocks))
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+			for _, b := range result.GoodBlocks {
+				blocks[b.Number] = b
+			}
+		}(from, to)
+	}
+
+	wg.Wait()
+
+	for n, blockLogs := range logs {
+		if n < tc.FromBlock || n > tc.ToBlock || len(blockLogs) == 0 {
+			continue
+		}
+
+		b, ok := blocks[n]
+		if !ok {
+			t.Fatalf("block %d missing from backfill results", n)
+		}
+
+		if len(b.Logs) != len(blockLogs) {
+			t.Fatalf("expecting %d logs in block %d, got %d", len(blockLogs), n, len(b.Logs))
+		}
+	}
+
+	if p.(*poller).tracker.sortedSet.GetCount() != 0 {
+		t.Fatal("Backfill should not track blocks")
+	}
+	// Chunks are polled out of order, so addresses discovered in a chunk would be missing from newer chunks
+	p.SetDiscovery(func(*types.Log) ([]common.Address, [][]common.Hash) { return nil, nil })
+	if _, err := p.Backfill(context.Background(), tc.FromBlock, tc.ToBlock); !errors.Is(err, superwatcher.ErrBadBackfill) {
+		t.Fatalf("expecting ErrBadBackfill with discovery, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/batch"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+)
+
+// blockWithTransactions is implemented by *reorgsim.Block,
+// which mocks full blocks returned by `eth_getBlockByNumber(n, true)`.
+type blockWithTransactions interface {
+	superwatcher.BlockHeader
+	Transactions() []*superwatcher.Transaction
+}
+
+// rpcTransaction is used to unmarshal full transaction objects in
+// `eth_getBlockByNumber` response, which includes fields not in *types.Transaction.
+type rpcTransaction struct {
+	tx *types.Transaction
+	txExtraInfo
+}
+
+type txExtraInfo struct {
+	Hash             common.Hash    `json:"hash"`
+	From             common.Address `json:"from"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+}
+
+func (tx *rpcTransaction) UnmarshalJSON(msg []byte) error {
+	if err := json.Unmarshal(msg, &tx.tx); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(msg, &tx.txExtraInfo)
+}
+
+// rpcBlockBody is used to unmarshal the transactions in `eth_getBlockByNumber` response.
+// The block header is unmarshaled separately into *types.Header from the same JSON message.
+type rpcBlockBody struct {
+	Transactions []rpcTransaction `json:"transactions"`
+}
+
+// blockByNumberBatch is an intermediate type for marshaling and
+// unmarshaling rpc.BatchElem for method batch.MethodGetBlockByNumber with full transactions.
+// It implements batch.Interface, so it can be passed to batch.CallBatch.
+type blockByNumberBatch struct {
+	client       string                      // filled by getBlocksByNumbers from reflect.TypeOf(client).String()
+	number       uint64                      // filled by getBlocksByNumbers
+	header       superwatcher.BlockHeader    // filled by blockByNumberBatch.Unmarshal
+	transactions []*superwatcher.Transaction // filled by blockByNumberBatch.Unmarshal
+}
+
+// Marshal returns BatchElem for calling RPC method `eth_getBlockByNumber` (`batch.MethodGetBlockByNumber`)
+// with h.Number and full transactions, and h.Result will be of type *json.RawMessage.
+func (b *blockByNumberBatch) Marshal() (rpc.BatchElem, error) {
+	// For mock testing with reorgsim
+	if b.client == "*reorgsim.ReorgSim" {
+		return rpc.BatchElem{
+			Method: batch.MethodGetBlockByNumber,
+			Args: []interface{}{
+				hexutil.EncodeBig(big.NewInt(int64(b.number))),
+				true,
+			},
+			Result: &reorgsim.Block{},
+			Error:  nil,
+		}, nil
+	}
+
+	return rpc.BatchElem{
+		Method: batch.MethodGetBlockByNumber,
+		Args: []interface{}{
+			hexutil.EncodeBig(big.NewInt(int64(b.number))),
+			true,
+		},
+		Result: &json.RawMessage{},
+		Error:  nil,
+	}, nil
+}
+
+// Unmarshal returns elem.Error if the node failed to get the block,
+// or if the node does not have block b.number.
+func (b *blockByNumberBatch) Unmarshal(elem rpc.BatchElem) error {
+	if elem.Error != nil {
+		return errors.Wrapf(elem.Error, "failed to get block %d", b.number)
+	}
+
+	switch result := elem.Result.(type) {
+	case *json.RawMessage:
+		if result == nil || len(*result) == 0 || string(*result) == "null" {
+			return fmt.Errorf("block %d not found", b.number)
+		}
+
+		header := new(types.Header)
+		if err := json.Unmarshal(*result, header); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal header for block %d", b.number)
+		}
+
+		var body rpcBlockBody
+		if err := json.Unmarshal(*result, &body); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal transactions for block %d", b.number)
+		}
+
+		b.header = superwatcher.BlockHeaderWrapper{Header: header}
+		b.transactions = make([]*superwatcher.Transaction, len(body.Transactions))
+		for i, tx := range body.Transactions {
+			b.transactions[i] = &superwatcher.Transaction{
+				Hash:  tx.Hash,
+				From:  tx.From,
+				Index: uint(tx.TransactionIndex),
+				Tx:    tx.tx,
+			}
+		}
+
+	case blockWithTransactions:
+		// e.g. when the client is reorgsim.ReorgSim, which overwrites elem.Result with *reorgsim.Block.
+		b.header = result
+		b.transactions = result.Transactions()
+
+	default:
+		return fmt.Errorf(
+			"unexpected result type for blockByNumberBatch: %s",
+			reflect.TypeOf(elem.Result).String(),
+		)
+	}
+
+	return nil
+}
+
+// getBlocksByNumbers gets full blocks (with transactions) in batch.
+// The returned superwatcher.Block only has Number, Hash, Header and Transactions filled.
+func getBlocksByNumbers(
+	ctx context.Context,
+	client superwatcher.EthClientRPC,
+	numbers []uint64,
+) (
+	map[uint64]*superwatcher.Block,
+	error,
+) {
+	typeOfClient := reflect.TypeOf(client).String()
+	elems := make([]batch.Interface, len(numbers))
+	for i := range numbers {
+		elems[i] = &blockByNumberBatch{
+			number: numbers[i],
+			client: typeOfClient,
+		}
+	}
+
+	// Call results will be stored in elems.
+	// Blocks are fetched in chunks, because |numbers| may exceed the node's batch limits.
+	if err := batch.CallBatchWithOptions(ctx, client, elems, batch.DefaultOptions); err != nil {
+		return nil, errors.Wrap(err, "failed to batch get blocks")
+	}
+
+	results := make(map[uint64]*superwatcher.Block)
+	for _, elem := range elems {
+		getBlockCall := elem.(*blockByNumberBatch)
+		results[getBlockCall.number] = &superwatcher.Block{
+			Number:       getBlockCall.number,
+			Hash:         getBlockCall.header.Hash(),
+			Header:       getBlockCall.header,
+			Transactions: getBlockCall.transactions,
+		}
+	}
+
+	return results, nil
+}
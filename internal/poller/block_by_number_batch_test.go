@@ -0,0 +1,103 @@
+package poller
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// blockJSON is a trimmed `eth_getBlockByNumber(n, true)` response with 1 legacy transaction
+const blockJSON = `{
+	"parentHash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+	"sha3Uncles": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+	"miner": "0x0000000000000000000000000000000000000000",
+	"stateRoot": "0x0000000000000000000000000000000000000000000000000000000000000002",
+	"transactionsRoot": "0x0000000000000000000000000000000000000000000000000000000000000003",
+	"receiptsRoot": "0x0000000000000000000000000000000000000000000000000000000000000004",
+	"logsBloom": "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+	"difficulty": "0x0",
+	"number": "0x45",
+	"gasLimit": "0x1c9c380",
+	"gasUsed": "0x5208",
+	"timestamp": "0x6400",
+	"extraData": "0x",
+	"mixHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+	"nonce": "0x0000000000000000",
+	"hash": "0x0000000000000000000000000000000000000000000000000000000000000069",
+	"transactions": [
+		{
+			"blockHash": "0x0000000000000000000000000000000000000000000000000000000000000069",
+			"blockNumber": "0x45",
+			"from": "0x00000000000000000000000000000000000000aa",
+			"to": "0x00000000000000000000000000000000000000bb",
+			"gas": "0x5208",
+			"gasPrice": "0x1",
+			"hash": "0x00000000000000000000000000000000000000000000000000000000000000cc",
+			"input": "0xdeadbeef",
+			"nonce": "0x0",
+			"transactionIndex": "0x2",
+			"value": "0x45",
+			"type": "0x0",
+			"v": "0x0",
+			"r": "0x0",
+			"s": "0x0"
+		}
+	]
+}`
+
+func TestBlockByNumberBatchUnmarshal(t *testing.T) {
+	b := &blockByNumberBatch{number: 0x45}
+	elem, err := b.Marshal()
+	if err != nil {
+		t.Fatal("unexpected Marshal error", err.Error())
+	}
+
+	if fullTxs, ok := elem.Args[1].(bool); !ok || !fullTxs {
+		t.Fatal("expecting full transactions argument")
+	}
+
+	raw := json.RawMessage(blockJSON)
+	if err := b.Unmarshal(rpc.BatchElem{Result: &raw}); err != nil {
+		t.Fatal("unexpected Unmarshal error", err.Error())
+	}
+
+	if n := b.header.Number(); n != 0x45 {
+		t.Fatalf("expecting block number %d, got %d", 0x45, n)
+	}
+	if l := len(b.transactions); l != 1 {
+		t.Fatalf("expecting 1 transaction, got %d", l)
+	}
+
+	tx := b.transactions[0]
+	if tx.Hash != common.HexToHash("0xcc") {
+		t.Errorf("unexpected tx hash %s", tx.Hash.String())
+	}
+	if tx.From != common.HexToAddress("0xaa") {
+		t.Errorf("unexpected tx sender %s", tx.From.String())
+	}
+	if tx.Index != 2 {
+		t.Errorf("unexpected tx index %d", tx.Index)
+	}
+	if to := tx.Tx.To(); to == nil || *to != common.HexToAddress("0xbb") {
+		t.Errorf("unexpected tx recipient %v", to)
+	}
+	if v := tx.Tx.Value().Uint64(); v != 0x45 {
+		t.Errorf("unexpected tx value %d", v)
+	}
+	if data := common.Bytes2Hex(tx.Tx.Data()); data != "deadbeef" {
+		t.Errorf("unexpected tx calldata %s", data)
+	}
+
+	null := json.RawMessage("null")
+	if err := b.Unmarshal(rpc.BatchElem{Result: &null}); err == nil {
+		t.Error("expecting error for null block")
+	}
+
+	elemErr := errors.New("header not found")
+	if err := b.Unmarshal(rpc.BatchElem{Result: &raw, Error: elemErr}); !errors.Is(err, elemErr) {
+		t.Error("expecting elem.Error, got", err)
+	}
+}
@@ -0,0 +1,158 @@
+package poller
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// unknownBlockErrors are (lowercased) substrings of errors returned by Ethereum nodes
+// when an EIP-234 block hash query targets a block hash the node does not know.
+var unknownBlockErrors = []string{
+	"unknown block",    // geth, Erigon
+	"header not found", // geth, various providers
+	"block not found",  // Nethermind, various providers
+}
+
+// codeResourceNotFound is the EIP-1474 JSON-RPC error code for requested resources that do not exist
+const codeResourceNotFound = -32001
+
+// isUnknownBlock returns whether |err| was returned because the node does not know the queried block hash.
+func isUnknownBlock(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == codeResourceNotFound {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range unknownBlockErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyLogsByBlockHash re-fetches logs with EIP-234 block hash queries (FilterQuery.BlockHash)
+// for blocks whose logs in |logs| have hashes different from |hashes|, i.e. when the range query
+// straddled a chain reorg. The returned logs are consistent per block: all logs of block n have hash hashes[n].
+// If the node does not know hashes[n] anymore, the block was orphaned after its header was fetched,
+// and errHashesDiffer is returned so that the range is re-polled.
+func verifyLogsByBlockHash(
+	ctx context.Context,
+	client superwatcher.EthClient,
+	addresses []common.Address,
+	topics [][]common.Hash,
+	logs []types.Log,
+	hashes map[uint64]common.Hash,
+	debugger *debugger.Debugger,
+) (
+	[]types.Log,
+	error,
+) {
+	inconsistent := make(map[uint64]bool)
+	for _, log := range logs {
+		if hash, ok := hashes[log.BlockNumber]; ok && hash != log.BlockHash {
+			inconsistent[log.BlockNumber] = true
+		}
+	}
+
+	if len(inconsistent) == 0 {
+		return logs, nil
+	}
+
+	numbers := make([]uint64, 0, len(inconsistent))
+	for n := range inconsistent {
+		numbers = append(numbers, n)
+	}
+
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+
+	debugger.Debug(
+		1, "logs inconsistent with block hashes, verifying with block hash queries",
+		zap.Uint64s("blocks", numbers),
+	)
+
+	verified := make([]types.Log, 0, len(logs))
+	for _, log := range logs {
+		if !inconsistent[log.BlockNumber] {
+			verified = append(verified, log)
+		}
+	}
+
+	for _, n := range numbers {
+		hash := hashes[n]
+		blockLogs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+			BlockHash: &hash,
+			Addresses: addresses,
+			Topics:    topics,
+		})
+		if err != nil {
+			if isUnknownBlock(err) {
+				return nil, errors.Wrapf(errHashesDiffer, "block %d hash %s was orphaned: %s", n, hashStr(hash), err.Error())
+			}
+
+			return nil, errors.Wrapf(superwatcher.ErrFetchError, "failed to get logs for block %d by hash: %s", n, err.Error())
+		}
+
+		verified = append(verified, blockLogs...)
+	}
+
+	sort.SliceStable(verified, func(i, j int) bool {
+		return verified[i].BlockNumber < verified[j].BlockNumber
+	})
+
+	return verified, nil
+}
+
+// hashesOfHeaders returns block hashes from |headers|
+func hashesOfHeaders(headers map[uint64]superwatcher.BlockHeader) map[uint64]common.Hash {
+	hashes := make(map[uint64]common.Hash, len(headers))
+	for n, header := range headers {
+		hashes[n] = header.Hash()
+	}
+
+	return hashes
+}
+
+// blockNumbersOfLogs returns sorted, unique block numbers of |logs| within range [fromBlock, toBlock]
+func blockNumbersOfLogs(
+	fromBlock uint64,
+	toBlock uint64,
+	logs []types.Log,
+) []uint64 {
+	seen := make(map[uint64]bool)
+	var numbers []uint64
+	for _, log := range logs {
+		n := log.BlockNumber
+		if n < fromBlock || n > toBlock || seen[n] {
+			continue
+		}
+
+		seen[n] = true
+		numbers = append(numbers, n)
+	}
+
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+
+	return numbers
+}
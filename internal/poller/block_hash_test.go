@@ -0,0 +1,134 @@
+package poller
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// straddlingClient answers range FilterLogs calls with |staleLogs| polled before ReorgSim was forked,
+// so that the polled logs are from the old chain, while the headers are from the new chain.
+type straddlingClient struct {
+	*reorgsim.ReorgSim
+	staleLogs []types.Log
+}
+
+func (c *straddlingClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if q.BlockHash != nil {
+		return c.ReorgSim.FilterLogs(ctx, q)
+	}
+
+	var logs []types.Log
+	for _, log := range c.staleLogs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+
+	return logs, nil
+}
+
+// TestVerifyBlockHash checks that with doVerifyBlockHash, logs polled from a range that straddles a chain reorg
+// are re-fetched with block hash queries, instead of the poller returning ErrChainIsReorging.
+func TestVerifyBlockHash(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	policies := []superwatcher.Policy{
+		superwatcher.PolicyNormal,
+		superwatcher.PolicyExpensive,
+		superwatcher.PolicyExpensiveBlock,
+	}
+
+	for _, policy := range policies {
+		for _, verify := range []bool{false, true} {
+			sim := newTestSim(t, tc.Param, logs, tc.Events[0])
+
+			// Poll the old chain's logs, then fork the chain before the poller gets the headers
+			q := ethereum.FilterQuery{FromBlock: big.NewInt(int64(tc.FromBlock)), ToBlock: big.NewInt(int64(tc.ToBlock))}
+			staleLogs, err := sim.FilterLogs(context.Background(), q)
+			if err != nil {
+				t.Fatal("unexpected FilterLogs error", err.Error())
+			}
+
+			forkSim(t, sim, tc.FromBlock, tc.ToBlock)
+
+			client := &straddlingClient{ReorgSim: sim, staleLogs: staleLogs}
+			p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, policy)
+			p.SetDoVerifyBlockHash(verify)
+
+			result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+			if !verify {
+				if !errors.Is(err, superwatcher.ErrChainIsReorging) {
+					t.Fatalf("[%s] expecting ErrChainIsReorging without verification, got %v", policy, err)
+				}
+
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("[%s] unexpected error with verification: %s", policy, err.Error())
+			}
+
+			for _, b := range result.GoodBlocks {
+				for _, log := range b.Logs {
+					if log.BlockHash != b.Hash {
+						t.Fatalf("[%s] log in block %d has hash different from block hash", policy, b.Number)
+					}
+				}
+
+				if b.Number < tc.Events[0].ReorgBlock {
+					continue
+				}
+
+				header, err := sim.HeaderByNumber(context.Background(), big.NewInt(int64(b.Number)))
+				if err != nil {
+					t.Fatal("unexpected HeaderByNumber error", err.Error())
+				}
+				if header.Hash() != b.Hash {
+					t.Fatalf("[%s] block %d is not from the new chain", policy, b.Number)
+				}
+			}
+		}
+	}
+}
+
+// rpcError mocks a JSON-RPC error with code
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestIsUnknownBlock(t *testing.T) {
+	tests := map[error]bool{
+		errors.New("unknown block"):                       true,
+		errors.New("header not found"):                    true,
+		errors.New("block not found"):                     true,
+		errors.New("method eth_getLogs not found"):        false,
+		errors.New("the method eth_getlogs is not found"): false,
+		rpcError{code: -32001, msg: "resource missing"}:   true,
+		rpcError{code: -32601, msg: "method not found"}:   false,
+		errors.Wrap(rpcError{code: -32001}, "wrapped"):    true,
+	}
+
+	for err, expected := range tests {
+		if actual := isUnknownBlock(err); actual != expected {
+			t.Errorf("unexpected isUnknownBlock result for \"%s\": expecting %v, got %v", err.Error(), expected, actual)
+		}
+	}
+
+	if isUnknownBlock(nil) {
+		t.Error("nil error is not unknown block")
+	}
+}
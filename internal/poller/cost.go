@@ -0,0 +1,65 @@
+package poller
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+)
+
+// takeCost sets the RPC cost of the poll to |result| if p.client implements superwatcher.CostReporter.
+// If the client is over its budget, the poller degrades itself with degrade.
+// Costs of calls made outside of Poll, e.g. by Backfill, are included in the next poll's cost.
+func (p *poller) takeCost(result *superwatcher.PollerResult, span uint64) {
+	reporter, ok := p.client.(superwatcher.CostReporter)
+	if !ok {
+		return
+	}
+
+	cost := reporter.TakeCost()
+	result.Cost = &cost
+
+	p.debugger.Debug(
+		2, "poll cost",
+		zap.Uint64("computeUnits", cost.ComputeUnits),
+		zap.Any("calls", cost.Calls),
+	)
+
+	if cost.OverBudget {
+		p.degrade(span)
+	}
+}
+
+// degrade reduces RPC usage of the next polls after the client went over its budget:
+// PolicyExpensive is switched to PolicyNormal, and the FilterLogs range limit is halved,
+// which also makes the emitter poll smaller ranges (see FilterRangeLimit). PolicyExpensiveBlock and PolicyHeaders
+// are kept, since switching them would change what the service gets, e.g. PolicyNormal has no block transactions.
+// The policy stays degraded until changed with SetPolicy,
+// while the range limit grows back like after the node rejects a range. Must be called with p locked.
+func (p *poller) degrade(span uint64) {
+	policy := p.policy
+	if policy == superwatcher.PolicyExpensive {
+		policy = superwatcher.PolicyNormal
+		if p.tracker != nil {
+			p.tracker.migratePolicy(p.policy, policy)
+		}
+	}
+
+	rangeLimit := p.rangeLimit
+	if rangeLimit == 0 {
+		rangeLimit = span
+	}
+	if rangeLimit > 1 {
+		rangeLimit /= 2
+	}
+
+	p.debugger.Warn(
+		1, "client over budget, degrading poller",
+		zap.String("fromPolicy", p.policy.String()),
+		zap.String("toPolicy", policy.String()),
+		zap.Uint64("newRangeLimit", rangeLimit),
+	)
+
+	p.policy = policy
+	p.rangeLimit = rangeLimit
+	p.rangeLimitOK = 0
+}
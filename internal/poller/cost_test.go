@@ -0,0 +1,101 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// costClient reports |overBudget| from TakeCost
+type costClient struct {
+	superwatcher.EthClient
+
+	overBudget bool
+}
+
+func (c *costClient) TakeCost() superwatcher.Cost {
+	return superwatcher.Cost{ComputeUnits: 100, OverBudget: c.overBudget}
+}
+
+// TestDegrade checks that the poller reports costs, and degrades its policy and range limit when over budget.
+func TestDegrade(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	sim := newTestSim(t, tc.Param, logs)
+
+	client := &costClient{EthClient: sim}
+	span := tc.ToBlock - tc.FromBlock + 1
+	p := New(nil, nil, true, true, span, client, 1, superwatcher.PolicyExpensive)
+
+	result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected poll error", err.Error())
+	}
+	if result.Cost == nil || result.Cost.ComputeUnits != 100 {
+		t.Fatalf("unexpected cost %+v", result.Cost)
+	}
+	if p.Policy() != superwatcher.PolicyExpensive || p.FilterRangeLimit() != 0 {
+		t.Fatalf("unexpected degrade without exceeding budget: policy %s, range limit %d", p.Policy(), p.FilterRangeLimit())
+	}
+
+	client.overBudget = true
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected poll error", err.Error())
+	}
+	if p.Policy() != superwatcher.PolicyNormal {
+		t.Fatalf("expecting policy %s after exceeding budget, got %s", superwatcher.PolicyNormal, p.Policy())
+	}
+	if limit := p.FilterRangeLimit(); limit != span/2 {
+		t.Fatalf("expecting range limit %d after exceeding budget, got %d", span/2, limit)
+	}
+
+	// Polls still work with the degraded poller
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.FromBlock+span/2-1); err != nil {
+		t.Fatal("unexpected poll error after degrading", err.Error())
+	}
+	if limit := p.FilterRangeLimit(); limit != span/4 {
+		t.Fatalf("expecting range limit %d after exceeding budget twice, got %d", span/4, limit)
+	}
+}
+
+// TestDegradeKeepPolicy checks that degrading only halves the range limit with policies
+// whose results would change with PolicyNormal.
+func TestDegradeKeepPolicy(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+	span := tc.ToBlock - tc.FromBlock + 1
+
+	for _, policy := range []superwatcher.Policy{superwatcher.PolicyExpensiveBlock, superwatcher.PolicyHeaders} {
+		client := &costClient{EthClient: newTestSim(t, tc.Param, logs), overBudget: true}
+		p := New(nil, nil, true, true, span, client, 1, policy)
+
+		if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+			t.Fatalf("[%s] unexpected poll error: %s", policy, err.Error())
+		}
+		if p.Policy() != policy {
+			t.Fatalf("[%s] unexpected policy after exceeding budget: %s", policy, p.Policy())
+		}
+		if limit := p.FilterRangeLimit(); limit != span/2 {
+			t.Fatalf("[%s] expecting range limit %d after exceeding budget, got %d", policy, span/2, limit)
+		}
+
+		// Transactions are still polled after degrading
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.FromBlock+span/2-1)
+		if err != nil {
+			t.Fatalf("[%s] unexpected poll error after degrading: %s", policy, err.Error())
+		}
+		if policy != superwatcher.PolicyExpensiveBlock {
+			continue
+		}
+
+		for _, b := range result.GoodBlocks {
+			if len(b.Logs) != 0 && len(b.Transactions) == 0 {
+				t.Fatalf("[%s] expecting transactions in block %d after degrading", policy, b.Number)
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package poller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+)
+
+// discoveredAddress records the block of the log that created a discovered address
+type discoveredAddress struct {
+	blockNumber uint64
+	blockHash   common.Hash
+}
+
+// SetDiscovery sets the hook used to discover new addresses and topics from polled logs. Nil disables discovery.
+func (p *poller) SetDiscovery(discovery superwatcher.DiscoveryFunc) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.discovery = discovery
+	if p.discovered == nil {
+		p.discovered = make(map[common.Address]discoveredAddress)
+	}
+}
+
+// discoverAndBackfill calls p.discovery on all logs in |pollResults|, and starts watching the discovered addresses.
+// Logs of the new addresses are then backfilled from their creation block to param.toBlock, and merged into |pollResults|.
+// The backfilled logs are also passed to p.discovery, so that children of discovered contracts are discovered too.
+func (p *poller) discoverAndBackfill(
+	ctx context.Context,
+	param *param,
+	client superwatcher.EthClient,
+	pollResults map[uint64]*mapLogsResult,
+) error {
+	// Without addresses or subscriptions, the poller is already watching all addresses
+	if len(p.addresses) == 0 && len(p.subscriptions) == 0 {
+		return nil
+	}
+
+	results := pollResults
+	for {
+		addresses, fromBlock := p.discover(results)
+		if len(addresses) == 0 {
+			return nil
+		}
+
+		p.debugger.Debug(
+			1, "discovered new addresses, backfilling logs",
+			zap.Any("addresses", addresses),
+			zap.Uint64("fromBlock", fromBlock),
+			zap.Uint64("toBlock", param.toBlock),
+		)
+
+		backfillParam := *param
+		backfillParam.fromBlock = fromBlock
+
+		backfilled, err := poll(ctx, &backfillParam, addresses, p.topics, client, p.debugger)
+		if err != nil {
+			return errors.Wrap(err, "failed to backfill logs of discovered addresses")
+		}
+
+		if err := mergeResults(pollResults, backfilled); err != nil {
+			return err
+		}
+
+		results = backfilled
+	}
+}
+
+// discover calls p.discovery on logs in |results| in order, and adds new addresses and topics to p.addresses and p.topics.
+// It returns the new addresses, and the oldest block in which they were created.
+// Topics are only merged into existing non-empty topic positions, since adding positions would narrow the filter.
+func (p *poller) discover(
+	results map[uint64]*mapLogsResult,
+) (
+	[]common.Address,
+	uint64,
+) {
+	numbers := make([]uint64, 0, len(results))
+	for n := range results {
+		numbers = append(numbers, n)
+	}
+
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+
+	var newAddresses []common.Address
+	var fromBlock uint64
+	for _, n := range numbers {
+		for _, log := range results[n].Logs {
+			addresses, topics := p.discovery(log)
+			for _, address := range addresses {
+				if p.watching(address) {
+					// Rediscovered, e.g. on the new chain after a reorg
+					if d, ok := p.discovered[address]; ok && d.blockHash != log.BlockHash {
+						p.discovered[address] = discoveredAddress{blockNumber: log.BlockNumber, blockHash: log.BlockHash}
+					}
+
+					continue
+				}
+
+				p.addresses = append(p.addresses, address)
+				p.discovered[address] = discoveredAddress{blockNumber: log.BlockNumber, blockHash: log.BlockHash}
+				newAddresses = append(newAddresses, address)
+
+				if fromBlock == 0 || log.BlockNumber < fromBlock {
+					fromBlock = log.BlockNumber
+				}
+			}
+
+			p.mergeTopics(topics)
+		}
+	}
+
+	return newAddresses, fromBlock
+}
+
+// undiscover stops watching discovered addresses whose creating blocks are in |reorgedBlocks|
+func (p *poller) undiscover(reorgedBlocks []*superwatcher.Block) {
+	if len(p.discovered) == 0 || len(reorgedBlocks) == 0 {
+		return
+	}
+
+	reorgedHashes := make(map[common.Hash]bool)
+	for _, b := range reorgedBlocks {
+		reorgedHashes[b.Hash] = true
+	}
+
+	var removed []common.Address
+	for address, d := range p.discovered {
+		if !reorgedHashes[d.blockHash] {
+			continue
+		}
+
+		// Copy, since p.addresses may be shared with the caller of SetAddresses
+		addresses := make([]common.Address, 0, len(p.addresses))
+		for _, watched := range p.addresses {
+			if watched != address {
+				addresses = append(addresses, watched)
+			}
+		}
+
+		p.addresses = addresses
+
+		delete(p.discovered, address)
+		removed = append(removed, address)
+	}
+
+	if len(removed) != 0 {
+		p.debugger.Debug(1, "creating logs reorged, removed discovered addresses", zap.Any("addresses", removed))
+	}
+}
+
+func (p *poller) watching(address common.Address) bool {
+	for _, watched := range p.addresses {
+		if watched == address {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *poller) mergeTopics(topics [][]common.Hash) {
+	for i, hashes := range topics {
+		if i >= len(p.topics) || len(p.topics[i]) == 0 {
+			continue
+		}
+
+		for _, hash := range hashes {
+			var found bool
+			for _, existing := range p.topics[i] {
+				if existing == hash {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				p.topics[i] = append(p.topics[i], hash)
+			}
+		}
+	}
+}
+
+// mergeResults merges logs and blocks from |src| into |dst|. It returns errHashesDiffer if the same block
+// has different hashes in |dst| and |src|, i.e. the chain reorged between the 2 polls.
+func mergeResults(
+	dst map[uint64]*mapLogsResult,
+	src map[uint64]*mapLogsResult,
+) error {
+	for n, s := range src {
+		d, ok := dst[n]
+		if !ok {
+			dst[n] = s
+			continue
+		}
+
+		if d.Hash != s.Hash {
+			return errors.Wrapf(
+				errHashesDiffer, "block %d has different hashes in backfill: %s vs %s",
+				n, hashStr(d.Hash), hashStr(s.Hash),
+			)
+		}
+
+		d.Logs = mergeLogs(d.Logs, s.Logs)
+		if d.Header == nil {
+			d.Header = s.Header
+		}
+		if d.Transactions == nil {
+			d.Transactions = s.Transactions
+		}
+	}
+
+	return nil
+}
+
+// mergeLogs returns logs from |a| and |b| sorted by log index, without duplicates
+func mergeLogs(a, b []*types.Log) []*types.Log {
+	seen := make(map[uint]bool, len(a))
+	merged := make([]*types.Log, 0, len(a)+len(b))
+	for _, log := range append(append([]*types.Log{}, a...), b...) {
+		if seen[log.Index] {
+			continue
+		}
+
+		seen[log.Index] = true
+		merged = append(merged, log)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Index < merged[j].Index
+	})
+
+	return merged
+}
+
+// tagDefault tags untagged logs in |pollResults| with superwatcher.DefaultSubscriptionID. Untagged logs are those
+// backfilled for discovered addresses, which are watched as part of the default subscription.
+func tagDefault(pollResults map[uint64]*mapLogsResult, tags map[logKey][]string) {
+	if tags == nil {
+		return
+	}
+
+	for _, result := range pollResults {
+		for _, log := range result.Logs {
+			k := logKey{blockHash: log.BlockHash, index: log.Index}
+			if _, ok := tags[k]; !ok {
+				tags[k] = []string{superwatcher.DefaultSubscriptionID}
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+var (
+	testFactory = common.HexToAddress("0x5c69bee701ef814a2b6a3edd4b1652cb9cc5aa6f")
+	testChild   = common.HexToAddress("0x2d71707ad8bf52d2ec38523133a1e216d45a88af")
+)
+
+// newTestDiscovery returns a DiscoveryFunc that maps factory logs in block |createdAt| to testChild
+func newTestDiscovery(createdAt uint64) superwatcher.DiscoveryFunc {
+	return func(log *types.Log) ([]common.Address, [][]common.Hash) {
+		if log.Address == testFactory && log.BlockNumber == createdAt {
+			return []common.Address{testChild}, nil
+		}
+
+		return nil, nil
+	}
+}
+
+// TestDiscovery checks that a discovered address is watched and backfilled from its creation block in the same poll.
+func TestDiscovery(t *testing.T) {
+	sim, tc := newNoReorgSim(t)
+	createdAt := uint64(15944407)
+
+	p := New([]common.Address{testFactory}, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	p.SetDiscovery(newTestDiscovery(createdAt))
+
+	result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected Poll error", err.Error())
+	}
+
+	var childLogs int
+	for _, b := range result.GoodBlocks {
+		for i, log := range b.Logs {
+			if i != 0 && b.Logs[i-1].Index > log.Index {
+				t.Fatalf("logs in block %d not sorted after backfill", b.Number)
+			}
+
+			if log.Address == testChild {
+				if log.BlockNumber < createdAt {
+					t.Fatalf("unexpected child log in block %d before creation block %d", log.BlockNumber, createdAt)
+				}
+
+				childLogs++
+			}
+		}
+	}
+
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+	var expected int
+	for n, blockLogs := range logs {
+		if n < createdAt || n > tc.ToBlock {
+			continue
+		}
+
+		for _, log := range blockLogs {
+			if log.Address == testChild {
+				expected++
+			}
+		}
+	}
+
+	if childLogs != expected {
+		t.Fatalf("expecting %d backfilled child logs, got %d", expected, childLogs)
+	}
+
+	if addresses := p.Addresses(); len(addresses) != 2 || addresses[1] != testChild {
+		t.Fatalf("expecting discovered address to be watched, got %v", addresses)
+	}
+}
+
+// TestDiscoveryReorged checks that a discovered address is removed if its creating log is reorged away.
+func TestDiscoveryReorged(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	createdAt := uint64(15944419)
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	// Move the creating log to another block, which newTestDiscovery ignores
+	var txHashes []common.Hash
+	for _, log := range logs[createdAt] {
+		if log.Address == testFactory {
+			txHashes = append(txHashes, log.TxHash)
+		}
+	}
+
+	events := []reorgsim.ReorgEvent{{
+		ReorgBlock: tc.Events[0].ReorgBlock,
+		MovedLogs: map[uint64][]reorgsim.MoveLogs{
+			createdAt: {{NewBlock: 15944498, TxHashes: txHashes}},
+		},
+	}}
+
+	sim := newTestSim(t, tc.Param, logs, events...)
+
+	p := New([]common.Address{testFactory}, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	p.SetDiscovery(newTestDiscovery(createdAt))
+
+	// The 1st poll discovers testChild and triggers the reorg event in ReorgSim
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from 1st poll", err.Error())
+	}
+	if len(p.Addresses()) != 2 {
+		t.Fatalf("expecting discovered address to be watched, got %v", p.Addresses())
+	}
+
+	forkSim(t, sim, tc.FromBlock, tc.ToBlock)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from 2nd poll", err.Error())
+	}
+	if addresses := p.Addresses(); len(addresses) != 1 || addresses[0] != testFactory {
+		t.Fatalf("expecting discovered address to be removed after reorg, got %v", addresses)
+	}
+}
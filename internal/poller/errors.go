@@ -13,7 +13,4 @@ var (
 	// When the emitter sees this error, it will change status.isReorging to true, and
 	// the emitter will make the poller re-poll the block range (with extra look back blocks)
 	errHashesDiffer = errors.Wrap(superwatcher.ErrChainIsReorging, "blockHashes differ")
-
-	// We cannot change poller.policy on the fly for now - will lead to reorg bug.
-	errDowngradeLevel = errors.Wrap(superwatcher.ErrUserError, "policy cannot be downgraded") // nolint:unused
 )
@@ -0,0 +1,109 @@
+package poller
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// growRangeLimitAfter is the number of consecutive successful polls
+// after which the poller doubles its range limit, in case the node can now handle larger ranges.
+const growRangeLimitAfter = 10
+
+// rangeTooLargeErrors are (lowercased) substrings of errors returned by
+// Ethereum nodes and hosted node providers when the FilterLogs range is too large.
+var rangeTooLargeErrors = []string{
+	"query returned more than",            // geth, Infura: "query returned more than 10000 results"
+	"range too large",                     // Erigon, Nethermind, Alchemy, QuickNode: "block range too large"
+	"exceed maximum block range",          // Ankr: "exceed maximum block range: 5000"
+	"exceeds maximum block range",         // Various providers
+	"response size exceeded",              // Alchemy: "Log response size exceeded"
+	"too many results",                    // Various providers
+	"logs matched by query exceeds limit", // Chainstack, Moralis
+}
+
+// isRangeTooLarge returns whether |err| was returned because the FilterLogs range was too large.
+func isRangeTooLarge(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range rangeTooLargeErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterLogsSplitter wraps superwatcher.EthClient, and splits FilterLogs calls into
+// sub-queries of at most rangeLimit blocks each. If the node rejects a sub-query range,
+// rangeLimit is halved and the rejected range is retried, until the range is only 1 block.
+// The logs from all sub-queries are merged in order, so the caller sees 1 big FilterLogs call.
+type filterLogsSplitter struct {
+	superwatcher.EthClient
+
+	rangeLimit uint64 // Max number of blocks per FilterLogs call, 0 means no limit
+	shrunk     bool   // Set to true if rangeLimit was shrunk during any FilterLogs call
+
+	debugger *debugger.Debugger
+}
+
+func (c *filterLogsSplitter) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	// Let the wrapped client deal with weird queries
+	if q.BlockHash != nil || q.FromBlock == nil || q.ToBlock == nil {
+		return c.EthClient.FilterLogs(ctx, q)
+	}
+
+	fromBlock, toBlock := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	if fromBlock > toBlock {
+		return c.EthClient.FilterLogs(ctx, q)
+	}
+
+	var logs []types.Log
+	for start := fromBlock; start <= toBlock; {
+		end := toBlock
+		if c.rangeLimit != 0 && end-start+1 > c.rangeLimit {
+			end = start + c.rangeLimit - 1
+		}
+
+		q.FromBlock = big.NewInt(int64(start))
+		q.ToBlock = big.NewInt(int64(end))
+
+		chunk, err := c.EthClient.FilterLogs(ctx, q)
+		if err != nil {
+			size := end - start + 1
+			if size == 1 || !isRangeTooLarge(err) {
+				return nil, err
+			}
+
+			c.rangeLimit = size / 2
+			c.shrunk = true
+
+			c.debugger.Debug(
+				1, "filterLogs range too large, splitting range",
+				zap.Uint64("fromBlock", start),
+				zap.Uint64("toBlock", end),
+				zap.Uint64("newRangeLimit", c.rangeLimit),
+				zap.String("reason", err.Error()),
+			)
+
+			// Retry from the same start with smaller range
+			continue
+		}
+
+		logs = append(logs, chunk...)
+		start = end + 1
+	}
+
+	return logs, nil
+}
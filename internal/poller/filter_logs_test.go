@@ -0,0 +1,166 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// limitedClient mocks a hosted node that rejects FilterLogs calls with range larger than maxRange
+type limitedClient struct {
+	superwatcher.EthClient
+	maxRange uint64
+	calls    int
+}
+
+func (c *limitedClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.calls++
+	if size := q.ToBlock.Uint64() - q.FromBlock.Uint64() + 1; c.maxRange != 0 && size > c.maxRange {
+		return nil, fmt.Errorf("query returned more than 10000 results (range %d)", size)
+	}
+
+	return c.EthClient.FilterLogs(ctx, q)
+}
+
+// newNoReorgSim returns ReorgSim for testlogs.TestCasesV1[0] with reorg event after tc.ToBlock,
+// so that the number of FilterLogs calls does not trigger any reorg.
+func newNoReorgSim(t *testing.T) (*reorgsim.ReorgSim, *testlogs.TestConfig) {
+	tc := testlogs.TestCasesV1[0]
+	sim := newTestSim(t, tc.Param, reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...), reorgsim.ReorgEvent{ReorgBlock: tc.ToBlock + 1000})
+
+	return sim, tc
+}
+
+func TestIsRangeTooLarge(t *testing.T) {
+	tests := map[string]bool{
+		"query returned more than 10000 results":                             true,
+		"Log response size exceeded. You can make eth_getLogs requests with": true,
+		"exceed maximum block range: 5000":                                   true,
+		"eth_getLogs block range too large, range: 10001, max: 10000":        true,
+		"block range exceeds maximum block range of 2000":                    true,
+		"invalid block range":                                                false,
+		"invalid block range params":                                         false,
+		"connection refused":                                                 false,
+		"context deadline exceeded":                                          false,
+	}
+
+	for msg, expected := range tests {
+		if actual := isRangeTooLarge(errors.New(msg)); actual != expected {
+			t.Errorf("unexpected isRangeTooLarge result for \"%s\": expecting %v, got %v", msg, expected, actual)
+		}
+	}
+
+	if isRangeTooLarge(nil) {
+		t.Error("nil error is not range too large")
+	}
+}
+
+func TestFilterLogsSplitter(t *testing.T) {
+	sim, tc := newNoReorgSim(t)
+	q := ethereum.FilterQuery{
+		FromBlock: big.NewInt(int64(tc.FromBlock)),
+		ToBlock:   big.NewInt(int64(tc.ToBlock)),
+	}
+
+	expected, err := sim.FilterLogs(context.Background(), q)
+	if err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	for _, maxRange := range []uint64{1, 7, 20, 50, 100, 1000} {
+		client := &filterLogsSplitter{
+			EthClient: &limitedClient{EthClient: sim, maxRange: maxRange},
+			debugger:  debugger.NewDebugger("testFilterLogsSplitter", 1),
+		}
+
+		logs, err := client.FilterLogs(context.Background(), q)
+		if err != nil {
+			t.Fatalf("unexpected error with maxRange %d: %s", maxRange, err.Error())
+		}
+
+		if len(logs) != len(expected) {
+			t.Fatalf("maxRange %d: expecting %d logs, got %d", maxRange, len(expected), len(logs))
+		}
+
+		for i := range logs {
+			if logs[i].BlockNumber != expected[i].BlockNumber || logs[i].TxHash != expected[i].TxHash || logs[i].Index != expected[i].Index {
+				t.Fatalf("maxRange %d: logs[%d] not in order", maxRange, i)
+			}
+		}
+
+		if client.rangeLimit > maxRange {
+			t.Errorf("maxRange %d: rangeLimit %d not shrunk", maxRange, client.rangeLimit)
+		}
+
+		if span := tc.ToBlock - tc.FromBlock + 1; span > maxRange && !client.shrunk {
+			t.Errorf("maxRange %d: expecting shrunk to be true", maxRange)
+		}
+	}
+
+	// Errors other than range too large must not be split
+	client := &filterLogsSplitter{
+		EthClient: &failingClient{EthClient: sim},
+		debugger:  debugger.NewDebugger("testFilterLogsSplitter", 1),
+	}
+	if _, err := client.FilterLogs(context.Background(), q); err == nil {
+		t.Fatal("expecting error from failingClient")
+	}
+	if client.shrunk {
+		t.Fatal("unexpected split on non-range error")
+	}
+}
+
+type failingClient struct {
+	superwatcher.EthClient
+}
+
+func (c *failingClient) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestPollRangeLimit(t *testing.T) {
+	sim, tc := newNoReorgSim(t)
+	client := &limitedClient{EthClient: sim, maxRange: 30}
+	span := tc.ToBlock - tc.FromBlock + 1
+
+	p := New(nil, nil, true, true, span, client, 1, superwatcher.PolicyNormal)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected Poll error", err.Error())
+	}
+
+	limit := p.FilterRangeLimit()
+	if limit == 0 || limit > client.maxRange {
+		t.Fatalf("unexpected range limit %d for node max range %d", limit, client.maxRange)
+	}
+
+	// The limit is remembered, so subsequent polls should not hit the node limit again
+	client.calls = 0
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected Poll error", err.Error())
+	}
+	if expected := int((span + limit - 1) / limit); client.calls != expected {
+		t.Fatalf("expecting %d FilterLogs calls with remembered limit %d, got %d", expected, limit, client.calls)
+	}
+
+	// Node limit lifted - the poller range limit should grow until it's removed
+	client.maxRange = 0
+	for i := 0; i < 10*growRangeLimitAfter; i++ {
+		if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+			t.Fatal("unexpected Poll error", err.Error())
+		}
+	}
+
+	if limit := p.FilterRangeLimit(); limit != 0 {
+		t.Fatalf("expecting range limit to be removed, got %d", limit)
+	}
+}
@@ -0,0 +1,111 @@
+package poller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// finality represents the `safe` and `finalized` block numbers during a poll
+type finality struct {
+	safe      uint64
+	finalized uint64
+}
+
+func getFinality(ctx context.Context, client superwatcher.EthClient) (*finality, error) {
+	safe, err := client.SafeBlockNumber(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(superwatcher.ErrFetchError, "failed to get safe block: %s", err.Error())
+	}
+
+	finalized, err := client.FinalizedBlockNumber(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(superwatcher.ErrFetchError, "failed to get finalized block: %s", err.Error())
+	}
+
+	return &finality{
+		safe:      safe,
+		finalized: finalized,
+	}, nil
+}
+
+// of returns the finality status of block |number|
+func (f *finality) of(number uint64) superwatcher.Finality {
+	switch {
+	case number <= f.finalized:
+		return superwatcher.FinalityFinalized
+	case number <= f.safe:
+		return superwatcher.FinalitySafe
+	}
+
+	return superwatcher.FinalityLatest
+}
+
+// verifyFinalized compares tracker blocks that were already seen as finalized with fresh |pollResults|,
+// and returns superwatcher.ErrFinalizedBlockChanged if any of them changed. Unlike findReorg,
+// verifyFinalized does not fetch headers for finalized blocks missing from |pollResults|,
+// since finalized blocks can never be reorged. Each finalized block is only verified once:
+// after the check, it is removed from |tracker|, and is never tracked again (see addTrackerBlockPolicy).
+func verifyFinalized(
+	param *param,
+	tracker *blockTracker,
+	pollResults map[uint64]*mapLogsResult,
+	debugger *debugger.Debugger,
+) error {
+	if tracker == nil {
+		return nil
+	}
+
+	var verified []uint64
+	for n := param.fromBlock; n <= param.toBlock; n++ {
+		trackerBlock, ok := tracker.getTrackerBlock(n)
+		if !ok || trackerBlock.Finality != superwatcher.FinalityFinalized {
+			continue
+		}
+
+		pollResult, ok := pollResults[n]
+		if !ok {
+			if len(trackerBlock.Logs) != 0 {
+				return errors.Wrapf(
+					superwatcher.ErrFinalizedBlockChanged, "%d logs missing from finalized block %d",
+					len(trackerBlock.Logs), n,
+				)
+			}
+		} else if trackerBlock.Hash != pollResult.Hash || len(trackerBlock.Logs) != len(pollResult.Logs) {
+			return errors.Wrapf(
+				superwatcher.ErrFinalizedBlockChanged, "finalized block %d changed: hash %s -> %s, logs %d -> %d",
+				n, trackerBlock.String(), pollResult.String(), len(trackerBlock.Logs), len(pollResult.Logs),
+			)
+		}
+
+		verified = append(verified, n)
+	}
+
+	// Only remove the blocks after all of them were verified, so that the tracker is intact on error
+	for _, n := range verified {
+		if err := tracker.removeBlock(n); err != nil {
+			return errors.Wrap(superwatcher.ErrSuperwatcherBug, err.Error())
+		}
+
+		if n > tracker.verifiedUntil {
+			tracker.verifiedUntil = n
+		}
+	}
+
+	if len(verified) != 0 {
+		debugger.Debug(2, "stopped tracking verified finalized blocks", zap.Uint64s("blocks", verified))
+	}
+
+	return nil
+}
+
+// markFinality sets finality status for all blocks in |pollResults|
+func markFinality(pollResults map[uint64]*mapLogsResult, finality *finality) {
+	for n, result := range pollResults {
+		result.Finality = finality.of(n)
+	}
+}
@@ -0,0 +1,167 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// newFinalitySim returns ReorgSim for testlogs.TestCasesV1[0] with current block at tc.ToBlock,
+// and the `safe` and `finalized` blocks |safeDepth| and |finalizedDepth| blocks behind.
+func newFinalitySim(
+	t *testing.T,
+	reorgEvent reorgsim.ReorgEvent,
+	safeDepth uint64,
+	finalizedDepth uint64,
+) (
+	*reorgsim.ReorgSim,
+	*testlogs.TestConfig,
+) {
+	tc := testlogs.TestCasesV1[0]
+	param := tc.Param
+	param.StartBlock = tc.ToBlock
+	param.BlockProgress = 20
+	param.ExitBlock = tc.ToBlock + 1000
+	param.SafeDepth = safeDepth
+	param.FinalizedDepth = finalizedDepth
+
+	sim := newTestSim(t, param, reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...), reorgEvent)
+
+	return sim, tc
+}
+
+func TestFinality(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	safe, finalized := tc.ToBlock-30, tc.ToBlock-60
+	sim, _ := newFinalitySim(t, reorgsim.ReorgEvent{ReorgBlock: tc.ToBlock + 1000}, 30, 60)
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	p.SetDoFinality(true)
+	result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected Poll error", err.Error())
+	}
+
+	if result.SafeBlock != safe || result.FinalizedBlock != finalized {
+		t.Fatalf("unexpected safe and finalized blocks %d and %d", result.SafeBlock, result.FinalizedBlock)
+	}
+
+	for _, b := range result.GoodBlocks {
+		expected := superwatcher.FinalityLatest
+		switch {
+		case b.Number <= finalized:
+			expected = superwatcher.FinalityFinalized
+		case b.Number <= safe:
+			expected = superwatcher.FinalitySafe
+		}
+
+		if b.Finality != expected {
+			t.Fatalf("expecting finality %s for block %d, got %s", expected, b.Number, b.Finality)
+		}
+	}
+
+	// Finality must be checked only if the node supports the tags
+	sim, _ = newFinalitySim(t, reorgsim.ReorgEvent{ReorgBlock: tc.ToBlock + 1000}, 0, 0)
+	p = New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	p.SetDoFinality(true)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); !errors.Is(err, superwatcher.ErrFetchError) {
+		t.Fatalf("expecting ErrFetchError from node without finalized tag, got %v", err)
+	}
+}
+
+// TestFinalizedBlockChanged checks that the poller returns ErrFinalizedBlockChanged
+// if a block it saw as finalized is reorged.
+func TestFinalizedBlockChanged(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+
+	// The reorged block is finalized
+	sim, _ := newFinalitySim(t, reorgEvent, 10, tc.ToBlock-reorgEvent.ReorgBlock)
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	p.SetDoFinality(true)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from 1st poll", err.Error())
+	}
+
+	_, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if !errors.Is(err, superwatcher.ErrFinalizedBlockChanged) {
+		t.Fatalf("expecting ErrFinalizedBlockChanged, got %v", err)
+	}
+}
+
+// TestFinalityReorgBeforeFinalized checks that a block reorged before it was seen as finalized
+// is reported as a normal chain reorg, even if it is finalized by the time the poller sees the reorg.
+func TestFinalityReorgBeforeFinalized(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+
+	// The reorged block is not yet finalized during the 1st poll
+	sim, _ := newFinalitySim(t, reorgEvent, 10, tc.ToBlock-reorgEvent.ReorgBlock+1)
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	p.SetDoFinality(true)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from 1st poll", err.Error())
+	}
+
+	// Move the chain forward, so that the reorged block is finalized during the 2nd poll
+	for i := 0; i < 2; i++ {
+		if _, err := sim.BlockNumber(context.Background()); err != nil {
+			t.Fatal("unexpected BlockNumber error", err.Error())
+		}
+	}
+
+	result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected error from 2nd poll", err.Error())
+	}
+
+	if result.FinalizedBlock < reorgEvent.ReorgBlock {
+		t.Fatalf("expecting reorged block %d to be finalized, finalized block is %d", reorgEvent.ReorgBlock, result.FinalizedBlock)
+	}
+
+	if len(result.ReorgedBlocks) == 0 {
+		t.Fatal("expecting reorged blocks")
+	}
+}
+
+// TestFinalizedUntracked checks that finalized blocks are verified once, and are then no longer tracked.
+func TestFinalizedUntracked(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	sim, _ := newFinalitySim(t, reorgsim.ReorgEvent{ReorgBlock: tc.ToBlock + 1000}, 30, 60)
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	p.SetDoFinality(true)
+	tracker := p.(*poller).tracker
+
+	var lastFinalized uint64
+	for i := 0; i < 3; i++ {
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatalf("unexpected error from poll %d: %s", i+1, err.Error())
+		}
+
+		var trackedFinalized int
+		for _, b := range tracker.blocks() {
+			if b.Number <= lastFinalized {
+				t.Fatalf("poll %d: block %d was finalized in the last poll but is still tracked", i+1, b.Number)
+			}
+			if b.Finality == superwatcher.FinalityFinalized {
+				trackedFinalized++
+			}
+		}
+
+		// Blocks seen as finalized for the 1st time are tracked until they are verified in the next poll
+		if i == 0 && trackedFinalized == 0 {
+			t.Fatal("expecting newly finalized blocks to be tracked")
+		}
+
+		lastFinalized = result.FinalizedBlock
+	}
+}
@@ -52,9 +52,20 @@ func (h *headerByNumberBatch) Marshal() (rpc.BatchElem, error) {
 	}, nil
 }
 
+// Unmarshal returns elem.Error if the node failed to get the header,
+// or if the node does not have block h.number.
 func (h *headerByNumberBatch) Unmarshal(elem rpc.BatchElem) error {
+	if elem.Error != nil {
+		return errors.Wrapf(elem.Error, "failed to get header %d", h.number)
+	}
+
 	switch header := elem.Result.(type) {
 	case *types.Header:
+		// The node returns null for unknown blocks, leaving header empty
+		if header.Number == nil {
+			return errors.Errorf("header %d not found", h.number)
+		}
+
 		// If *types.Header, wrap with BlockHeaderWrapper to implement superwatcher.BlockHeader
 		h.header = superwatcher.BlockHeaderWrapper{Header: header}
 
@@ -90,8 +101,9 @@ func getHeadersByNumbers(
 		}
 	}
 
-	// Call results will be stored in elems
-	if err := batch.CallBatch(ctx, client, elems); err != nil {
+	// Call results will be stored in elems.
+	// Headers are fetched in chunks, because |numbers| may exceed the node's batch limits.
+	if err := batch.CallBatchWithOptions(ctx, client, elems, batch.DefaultOptions); err != nil {
 		return nil, errors.Wrap(err, "failed to batch get block headers")
 	}
 
@@ -0,0 +1,166 @@
+package poller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// pollHeaders returns headers for all blocks within range [param.fromBlock, param.toBlock].
+// Headers already in pollResults are reused, and only the rest are fetched from |client|.
+// pollHeaders also checks if the headers link to each other via their parent hashes,
+// and returns errHashesDiffer if they do not, i.e. the chain reorged while we were fetching.
+func pollHeaders(
+	ctx context.Context,
+	param *param,
+	client superwatcher.EthClient,
+	pollResults map[uint64]*mapLogsResult,
+	debugger *debugger.Debugger,
+) (
+	map[uint64]superwatcher.BlockHeader,
+	error,
+) {
+	headers := make(map[uint64]superwatcher.BlockHeader)
+
+	var targetBlocks []uint64
+	for n := param.fromBlock; n <= param.toBlock; n++ {
+		if result, ok := pollResults[n]; ok && result.Header != nil {
+			headers[n] = result.Header
+			continue
+		}
+
+		targetBlocks = append(targetBlocks, n)
+	}
+
+	if len(targetBlocks) != 0 {
+		debugger.Debug(
+			3, "polling headers for parent hash check",
+			zap.Uint64s("targetBlocks", targetBlocks),
+		)
+
+		fetched, err := getHeadersByNumbers(ctx, client, targetBlocks)
+		if err != nil {
+			return nil, errors.Wrap(superwatcher.ErrFetchError, "failed to get headers for parent hash check")
+		}
+
+		for n, header := range fetched {
+			headers[n] = header
+		}
+	}
+
+	for n := param.fromBlock; n <= param.toBlock; n++ {
+		header, ok := headers[n]
+		if !ok || header == nil {
+			return nil, errors.Wrapf(superwatcher.ErrFetchError, "missing header for block %d", n)
+		}
+
+		if n == param.fromBlock {
+			continue
+		}
+
+		if parentHash, prevHash := header.ParentHash(), headers[n-1].Hash(); parentHash != prevHash {
+			return nil, errors.Wrapf(
+				errHashesDiffer, "block %d parentHash differs from block %d hash: %s vs %s",
+				n, n-1, hashStr(parentHash), hashStr(prevHash),
+			)
+		}
+	}
+
+	return headers, nil
+}
+
+// findForkBlock compares fresh |headers| with known hashes in |canonical| to find the fork point,
+// i.e. the lowest block whose hash has changed. If the parent of fromBlock had its hash changed,
+// then the fork point is below fromBlock, and findForkBlock returns fromBlock with |deep| set to true.
+func findForkBlock(
+	param *param,
+	canonical *blockTracker,
+	headers map[uint64]superwatcher.BlockHeader,
+) (
+	forkBlock uint64,
+	found bool,
+	deep bool,
+) {
+	if param.fromBlock != 0 {
+		parent, ok := canonical.getTrackerBlock(param.fromBlock - 1)
+		if ok && parent.Hash != headers[param.fromBlock].ParentHash() {
+			return param.fromBlock, true, true
+		}
+	}
+
+	for n := param.fromBlock; n <= param.toBlock; n++ {
+		known, ok := canonical.getTrackerBlock(n)
+		if !ok {
+			continue
+		}
+
+		if known.Hash != headers[n].Hash() {
+			return n, true, false
+		}
+	}
+
+	return 0, false, false
+}
+
+// collectForkedBlocks adds canonical blocks from |forkBlock| to param.toBlock to result.ReorgedBlocks,
+// unless they were already reported as reorged (e.g. by findReorg). This allows the poller
+// to report reorged blocks that are not in the tracker, e.g. empty blocks with PolicyFast.
+func collectForkedBlocks(
+	param *param,
+	forkBlock uint64,
+	canonical *blockTracker,
+	result *superwatcher.PollerResult,
+	debugger *debugger.Debugger,
+) {
+	reported := make(map[uint64]bool)
+	for _, b := range result.ReorgedBlocks {
+		reported[b.Number] = true
+	}
+
+	var added []uint64
+	for n := forkBlock; n <= param.toBlock; n++ {
+		if reported[n] {
+			continue
+		}
+
+		known, ok := canonical.getTrackerBlock(n)
+		if !ok {
+			continue
+		}
+
+		// Copy to avoid result consumers mutating canonical blocks
+		copied := *known
+		result.ReorgedBlocks = append(result.ReorgedBlocks, &copied)
+		added = append(added, n)
+	}
+
+	if len(added) == 0 {
+		return
+	}
+
+	sort.Slice(result.ReorgedBlocks, func(i, j int) bool {
+		return result.ReorgedBlocks[i].Number < result.ReorgedBlocks[j].Number
+	})
+
+	debugger.Debug(
+		1, "reorged blocks found by parent hash check",
+		zap.Uint64("forkBlock", forkBlock),
+		zap.Uint64s("blocks", added),
+	)
+}
+
+// updateCanonical saves fresh |headers| to |canonical| as the current canonical chain.
+func updateCanonical(canonical *blockTracker, headers map[uint64]superwatcher.BlockHeader) {
+	for n, header := range headers {
+		canonical.addTrackerBlock(&superwatcher.Block{
+			Number: n,
+			Hash:   header.Hash(),
+			Header: header,
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// TestParentHash checks that with doParentHash, the poller reports the exact fork point
+// even with PolicyFast, which does not track empty blocks.
+func TestParentHash(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	for _, policy := range allPolicies {
+		client := newTestSim(t, tc.Param, logs, reorgEvent)
+
+		p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, policy)
+		p.SetDoParentHash(true)
+
+		// The 1st poll triggers the reorg event in ReorgSim, the 2nd poll sees the reorged chain.
+		if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+			t.Fatalf("[%s] unexpected error from 1st poll: %s", policy, err.Error())
+		}
+
+		forkSim(t, client, tc.FromBlock, tc.ToBlock)
+
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatalf("[%s] unexpected error from 2nd poll: %s", policy, err.Error())
+		}
+
+		// All blocks from the fork point must be reported, including empty ones
+		if expected, actual := tc.ToBlock-reorgEvent.ReorgBlock+1, uint64(len(result.ReorgedBlocks)); expected != actual {
+			t.Fatalf("[%s] expecting %d reorged blocks, got %d", policy, expected, actual)
+		}
+
+		for i, b := range result.ReorgedBlocks {
+			if expected := reorgEvent.ReorgBlock + uint64(i); b.Number != expected {
+				t.Fatalf("[%s] expecting reorged block %d, got %d", policy, expected, b.Number)
+			}
+		}
+
+		if expected := reorgEvent.ReorgBlock - 1; result.LastGoodBlock != expected {
+			t.Fatalf("[%s] expecting lastGoodBlock %d, got %d", policy, expected, result.LastGoodBlock)
+		}
+	}
+}
+
+// TestParentHashDeepFork checks that the poller returns ErrFromBlockReorged
+// when the fork point is below fromBlock, even if there's no tracked blocks in between.
+func TestParentHashDeepFork(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	client := newTestSim(t, tc.Param, logs, reorgEvent)
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyFast)
+	p.SetDoParentHash(true)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from 1st poll", err.Error())
+	}
+
+	forkSim(t, client, tc.FromBlock, tc.ToBlock)
+
+	// Poll from after the fork point, so that only the parent of fromBlock was reorged
+	_, err := p.Poll(context.Background(), reorgEvent.ReorgBlock+1, tc.ToBlock)
+	if !errors.Is(err, superwatcher.ErrFromBlockReorged) {
+		t.Fatalf("expecting ErrFromBlockReorged, got %v", err)
+	}
+}
+
+// TestParentHashNoReorg checks that without doReorg, forked blocks are not reported,
+// and that the canonical tracker is still cleared, so that it does not grow forever.
+func TestParentHashNoReorg(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	forked := newTestSim(t, tc.Param, logs, reorgEvent)
+	p := New(nil, nil, false, true, tc.ToBlock-tc.FromBlock+1, forked, 1, superwatcher.PolicyFast)
+	p.SetDoParentHash(true)
+
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from 1st poll", err.Error())
+	}
+
+	forkSim(t, forked, tc.FromBlock, tc.ToBlock)
+
+	result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected error from 2nd poll", err.Error())
+	}
+	if len(result.ReorgedBlocks) != 0 {
+		t.Fatalf("expecting no reorged blocks without doReorg, got %d", len(result.ReorgedBlocks))
+	}
+
+	client := newTestSim(t, tc.Param, logs)
+	filterRange := uint64(10)
+	p = New(nil, nil, false, true, filterRange, client, 1, superwatcher.PolicyFast)
+	p.SetDoParentHash(true)
+
+	canonical := p.(*poller).canonical
+	for fromBlock := tc.FromBlock; fromBlock+filterRange-1 <= tc.ToBlock; fromBlock += filterRange {
+		if _, err := p.Poll(context.Background(), fromBlock, fromBlock+filterRange-1); err != nil {
+			t.Fatalf("unexpected error from poll [%d, %d]: %s", fromBlock, fromBlock+filterRange-1, err.Error())
+		}
+
+		// Blocks in range, blocks in the last filterRange, and the parent of fromBlock
+		if limit := 2*filterRange + 1; uint64(canonical.Len()) > limit {
+			t.Fatalf("expecting at most %d canonical blocks after polling from %d, got %d", limit, fromBlock, canonical.Len())
+		}
+	}
+}
@@ -24,6 +24,9 @@ type param struct {
 	fromBlock uint64
 	toBlock   uint64
 	policy    superwatcher.Policy
+
+	verifyBlockHash bool                        // Re-fetch logs inconsistent with their headers with EIP-234 block hash queries
+	predicates      []superwatcher.LogPredicate // Only logs matching all predicates are kept
 }
 
 func (p *poller) Poll(
@@ -37,26 +40,100 @@ func (p *poller) Poll(
 	p.Lock()
 	defer p.Unlock()
 
-	if p.tracker != nil || p.lastRecordedBlock != 0 {
+	if err := p.loadTracker(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to load tracker from store")
+	}
+
+	if p.lastRecordedBlock > p.filterRange {
 		// Clear all tracker's blocks before fromBlock - filterRange
 		until := p.lastRecordedBlock - p.filterRange
-		p.debugger.Debug(2, "clearing tracker", zap.Uint64("untilBlock", until))
-		p.tracker.clearUntil(until)
+		// Never clear blocks in this poll's range, e.g. when the last poll went further than
+		// the emitter did because of confirmations
+		if fromBlock != 0 && until >= fromBlock {
+			until = fromBlock - 1
+		}
+
+		if p.tracker != nil {
+			p.debugger.Debug(2, "clearing tracker", zap.Uint64("untilBlock", until))
+			p.tracker.clearUntil(until)
+		}
+
+		// Keep 1 more block in canonical, so that we can check parent hash of the next fromBlock
+		if p.canonical != nil && until != 0 {
+			p.canonical.clearUntil(until - 1)
+		}
 	}
 
+	// ethClient retries failed RPC calls with p.retryPolicy
+	ethClient := withRetry(p.client, p.retryPolicy, p.debugger)
+
 	param := &param{
 		fromBlock: fromBlock,
 		toBlock:   toBlock,
 		policy:    p.policy,
+
+		verifyBlockHash: p.doVerifyBlockHash,
+		predicates:      p.predicates,
+	}
+
+	// Get finality before logs, so that blocks seen as finalized were already finalized when their logs were polled
+	var finality *finality
+	if p.doFinality {
+		var err error
+		finality, err = getFinality(ctx, ethClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// client splits FilterLogs range if the node rejects it
+	client := &filterLogsSplitter{
+		EthClient:  withAddressChunks(ethClient, p.addressChunkSize, p.debugger),
+		rangeLimit: p.rangeLimit,
+		debugger:   p.debugger,
 	}
 
-	pollResults, err := poll(ctx, param, p.addresses, p.topics, p.client, p.debugger)
+	// subsClient queries each subscription separately, if there's any
+	var pollClient superwatcher.EthClient = client
+	var subsClient *subscriptionsClient
+	if subs := p.querySubscriptions(); subs != nil {
+		subsClient = &subscriptionsClient{
+			EthClient:     client,
+			subscriptions: subs,
+			debugger:      p.debugger,
+		}
+		pollClient = subsClient
+	}
+
+	pollResults, err := poll(ctx, param, p.addresses, p.topics, pollClient, p.debugger)
+	p.updateRangeLimit(client, err == nil, toBlock-fromBlock+1)
 	if err != nil {
 		return nil, err
 	}
 
+	// Watch and backfill addresses discovered from the logs, before the logs are tagged
+	if p.discovery != nil {
+		if err := p.discoverAndBackfill(ctx, param, client, pollResults); err != nil {
+			return nil, err
+		}
+
+		if subsClient != nil {
+			tagDefault(pollResults, subsClient.tags)
+		}
+	}
+
+	if subsClient != nil {
+		tagLogs(pollResults, subsClient.tags)
+	}
+
+	if finality != nil {
+		if err := verifyFinalized(param, p.tracker, pollResults, p.debugger); err != nil {
+			return nil, err
+		}
+	}
+
 	var blocksMissing []uint64
-	pollResults, blocksMissing, err = pollMissing(ctx, param, p.client, p.tracker, pollResults, p.debugger)
+	pollResults, blocksMissing, err = pollMissing(ctx, param, ethClient, p.tracker, pollResults, p.debugger)
 	if err != nil {
 		return nil, err
 	}
@@ -66,14 +143,59 @@ func (p *poller) Poll(
 		return nil, err
 	}
 
+	// Check parent hashes of all blocks in range to find the fork point
+	var headers map[uint64]superwatcher.BlockHeader
+	var forkBlock uint64
+	var forked, deepFork bool
+	if p.doParentHash && p.canonical != nil {
+		headers, err = pollHeaders(ctx, param, ethClient, pollResults, p.debugger)
+		if err != nil {
+			return nil, err
+		}
+
+		forkBlock, forked, deepFork = findForkBlock(param, p.canonical, headers)
+	}
+
+	if finality != nil {
+		markFinality(pollResults, finality)
+	}
+
 	result, err := processResult(param, p.tracker, pollResults, p.debugger)
 	if err != nil {
 		return result, err
 	}
 
+	if forked && p.doReorg {
+		collectForkedBlocks(param, forkBlock, p.canonical, result, p.debugger)
+	}
+	if p.discovery != nil {
+		p.undiscover(result.ReorgedBlocks)
+	}
+	if headers != nil {
+		updateCanonical(p.canonical, headers)
+	}
+
+	if p.doReceipts {
+		if err := pollReceipts(ctx, ethClient, p.tracker, result, p.debugger); err != nil {
+			return result, errors.Wrap(err, "pollReceipts error")
+		}
+	}
+
 	result.FromBlock, result.ToBlock = fromBlock, toBlock
+	if finality != nil {
+		result.SafeBlock, result.FinalizedBlock = finality.safe, finality.finalized
+	}
+
 	result.LastGoodBlock = superwatcher.LastGoodBlock(result)
 	p.lastRecordedBlock = result.LastGoodBlock
+	p.saveTracker(ctx)
+	p.takeCost(result, toBlock-fromBlock+1)
+
+	if deepFork && p.doReorg {
+		return result, errors.Wrapf(
+			superwatcher.ErrFromBlockReorged, "parent of fromBlock %d was removed/reorged", fromBlock,
+		)
+	}
 
 	fromBlockResult, ok := pollResults[fromBlock]
 	if ok {
@@ -100,18 +222,30 @@ func poll(
 ) {
 	pollResults := make(map[uint64]*mapLogsResult)
 
+	var err error
 	switch {
-	case param.policy >= superwatcher.PolicyExpensiveBlock: // Get blocks and event logs concurrently
-		return nil, errors.New("PolicyExpensiveBlock not implemented")
+	case param.policy == superwatcher.PolicyExpensiveBlock: // Get blocks and event logs concurrently
+		pollResults, err = pollExpensiveBlock(ctx, param.fromBlock, param.toBlock, addresses, topics, client, param.verifyBlockHash, pollResults, debugger)
 
 	case param.policy == superwatcher.PolicyExpensive:
-		return pollExpensive(ctx, param.fromBlock, param.toBlock, addresses, topics, client, pollResults, debugger)
+		pollResults, err = pollExpensive(ctx, param.fromBlock, param.toBlock, addresses, topics, client, param.verifyBlockHash, pollResults, debugger)
+
+	case param.policy == superwatcher.PolicyHeaders: // Get headers only, without event logs
+		pollResults, err = pollHeadersOnly(ctx, param.fromBlock, param.toBlock, client, pollResults, debugger)
 
 	case param.policy <= superwatcher.PolicyNormal:
-		return pollCheap(ctx, param.fromBlock, param.toBlock, addresses, topics, client, pollResults, debugger)
+		pollResults, err = pollCheap(ctx, param.fromBlock, param.toBlock, addresses, topics, client, param.verifyBlockHash, pollResults, debugger)
+
+	default:
+		panic(superwatcher.ErrBadPolicy.Error() + " " + param.policy.String())
 	}
 
-	panic(superwatcher.ErrBadPolicy.Error() + " " + param.policy.String())
+	if err != nil {
+		return pollResults, err
+	}
+
+	filterPredicates(param, pollResults, debugger)
+	return pollResults, nil
 }
 
 // pollMissing polls tracker blocks that were removed/reorged and thus currently missing from the chain.
@@ -187,6 +321,7 @@ func pollMissing(
 
 // findReorg compares fresh block hashes with known hashes in tracker.
 // If block hashes and logs length do not match, findReorg marks the block as reorged.
+// For blocks tracked with PolicyHeaders before a policy change, only the hashes are compared.
 func findReorg(
 	param *param,
 	blocksMissing []uint64,
@@ -214,8 +349,11 @@ func findReorg(
 			return nil, errors.Wrapf(superwatcher.ErrProcessReorg, "pollResult missing for trackerBlock %d", n)
 		}
 
-		if trackerBlock.Hash == pollResult.Hash && len(trackerBlock.Logs) == len(pollResult.Logs) {
-			continue
+		if trackerBlock.Hash == pollResult.Hash {
+			// Logs of blocks tracked with PolicyHeaders are unknown, so only hashes are compared
+			if len(trackerBlock.Logs) == len(pollResult.Logs) || tracker.isHeadersOnly(n) {
+				continue
+			}
 		}
 
 		if gsl.Contains(blocksMissing, n) {
@@ -315,6 +453,65 @@ func processResult(
 	return result, nil
 }
 
+// pollReceipts attaches transaction receipts to result.GoodBlocks with logs, and drops receipts from result.ReorgedBlocks.
+// Receipts of blocks whose hashes did not change since the last call are reused from tracker if possible.
+func pollReceipts(
+	ctx context.Context,
+	client superwatcher.EthClient,
+	tracker *blockTracker,
+	result *superwatcher.PollerResult,
+	debugger *debugger.Debugger,
+) error {
+	// Receipts of reorged blocks are stale
+	for _, b := range result.ReorgedBlocks {
+		b.Receipts = nil
+	}
+
+	var targetBlocks []*superwatcher.Block
+	for _, b := range result.GoodBlocks {
+		if len(b.Logs) == 0 {
+			continue
+		}
+
+		if tracker != nil {
+			trackerBlock, ok := tracker.getTrackerBlock(b.Number)
+			if ok && trackerBlock.Hash == b.Hash && trackerBlock.Receipts != nil {
+				b.Receipts = trackerBlock.Receipts
+				continue
+			}
+		}
+
+		targetBlocks = append(targetBlocks, b)
+	}
+
+	if len(targetBlocks) == 0 {
+		return nil
+	}
+
+	receipts, err := getReceipts(ctx, client, targetBlocks, debugger)
+	if err != nil {
+		if errors.Is(err, errHashesDiffer) {
+			return err
+		}
+
+		return errors.Wrap(superwatcher.ErrFetchError, err.Error())
+	}
+
+	for _, b := range targetBlocks {
+		b.Receipts = receipts[b.Number]
+
+		// Save receipts to tracker, so that we don't have to get them again in the next call
+		if tracker != nil {
+			if trackerBlock, ok := tracker.getTrackerBlock(b.Number); ok && trackerBlock.Hash == b.Hash {
+				trackerBlock.Receipts = b.Receipts
+			}
+		}
+	}
+
+	debugger.Debug(2, "polled receipts", zap.Int("blocks", len(targetBlocks)))
+	return nil
+}
+
 // handleBlocksMissingPolicy handles blocks that is marked with LogsMigrated (0 logs)
 func handleBlocksMissingPolicy(
 	number uint64,
@@ -343,10 +540,14 @@ func handleBlocksMissingPolicy(
 
 // addTrackerBlockPolicy adds blocks to tracker based on PollPolicy.
 // PolicyCheap will not save blocks with 0 logs to tracker, so as to avoid expensive header fetching.
+// Finalized blocks already verified by verifyFinalized are not tracked again.
 func addTrackerBlockPolicy(tracker *blockTracker, block *superwatcher.Block, policy superwatcher.Policy) {
 	if tracker == nil {
 		return
 	}
+	if block.Finality == superwatcher.FinalityFinalized && block.Number <= tracker.verifiedUntil {
+		return
+	}
 	if policy == superwatcher.PolicyFast {
 		if len(block.Logs) == 0 {
 			return
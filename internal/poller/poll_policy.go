@@ -25,6 +25,7 @@ func pollExpensive(
 	addresses []common.Address,
 	topics [][]common.Hash,
 	client superwatcher.EthClient,
+	verifyBlockHash bool,
 	pollResults map[uint64]*mapLogsResult,
 	debugger *debugger.Debugger,
 ) (
@@ -85,6 +86,14 @@ func pollExpensive(
 		return nil, errors.Wrap(superwatcher.ErrFetchError, "headers and blockNumbers length not matched")
 	}
 
+	if verifyBlockHash {
+		var err error
+		logs, err = verifyLogsByBlockHash(ctx, client, addresses, topics, logs, hashesOfHeaders(headers), debugger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Collect logs into map
 	_, err := collectLogs(pollResults, logs)
 	if err != nil {
@@ -110,6 +119,114 @@ func pollExpensive(
 	return pollResults, nil
 }
 
+// pollExpensiveBlock concurrently fetches event logs and full blocks (with transactions)
+// for all blocks within range [fromBlock, toBlock] and save them in pollResults.
+func pollExpensiveBlock(
+	ctx context.Context,
+	fromBlock uint64,
+	toBlock uint64,
+	addresses []common.Address,
+	topics [][]common.Hash,
+	client superwatcher.EthClient,
+	verifyBlockHash bool,
+	pollResults map[uint64]*mapLogsResult,
+	debugger *debugger.Debugger,
+) (
+	map[uint64]*mapLogsResult,
+	error,
+) {
+	// pollExpensiveBlock will get blocks for ALL blocks within range
+	numBlocks := toBlock - fromBlock + 1 // For pre-alloc with size of all blocks
+	blockNumbers := make([]uint64, numBlocks)
+	var c int
+	for n := fromBlock; n <= toBlock; n++ {
+		blockNumbers[c] = n
+		c++
+	}
+
+	var blocks map[uint64]*superwatcher.Block
+	var logs []types.Log
+	q := ethereum.FilterQuery{
+		FromBlock: big.NewInt(int64(fromBlock)),
+		ToBlock:   big.NewInt(int64(toBlock)),
+		Addresses: addresses,
+		Topics:    topics,
+	}
+
+	errChan := make(chan error)
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		var err error
+		logs, err = client.FilterLogs(ctx, q)
+		if err != nil {
+			errChan <- errors.Wrapf(superwatcher.ErrFetchError, "filterLogs returned error")
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		var err error
+		blocks, err = getBlocksByNumbers(ctx, client, blockNumbers)
+		if err != nil {
+			errChan <- errors.Wrap(superwatcher.ErrFetchError, err.Error())
+		}
+	}()
+
+	if err := concurrent.WaitAndCollectErrors(&wg, errChan); err != nil {
+		return nil, errors.Wrap(err, "concurrent fetch error")
+	}
+
+	debugger.Debug(
+		2, "polled event logs and blocks",
+		zap.Int("logs", len(logs)),
+		zap.Int("blocks", len(blocks)),
+	)
+
+	if len(blockNumbers) != len(blocks) {
+		return nil, errors.Wrap(superwatcher.ErrFetchError, "blocks and blockNumbers length not matched")
+	}
+
+	if verifyBlockHash {
+		hashes := make(map[uint64]common.Hash, len(blocks))
+		for n, b := range blocks {
+			hashes[n] = b.Hash
+		}
+
+		var err error
+		logs, err = verifyLogsByBlockHash(ctx, client, addresses, topics, logs, hashes, debugger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Collect logs into map
+	_, err := collectLogs(pollResults, logs)
+	if err != nil {
+		// If hashes differ in this round, return the pollResults with the error to repoll.
+		if errors.Is(err, errHashesDiffer) {
+			return pollResults, err
+		}
+
+		return nil, errors.Wrap(err, "collectLogs found error")
+	}
+
+	_, err = collectBlocks(pollResults, fromBlock, toBlock, blocks)
+	if err != nil {
+		// If hashes differ in this round, return the pollResults with the error to repoll.
+		if errors.Is(err, errHashesDiffer) {
+			return pollResults, err
+		}
+
+		return nil, errors.Wrap(err, "collectBlocks found error")
+	}
+
+	debugger.Debug(3, "pollExpensiveBlock successful")
+	return pollResults, nil
+}
+
 // pollCheap polls event logs first, and then block headers for blocks with logs.
 // The results will be written to pollResults.
 func pollCheap(
@@ -119,6 +236,7 @@ func pollCheap(
 	addresses []common.Address,
 	topics [][]common.Hash,
 	client superwatcher.EthClient,
+	verifyBlockHash bool,
 	pollResults map[uint64]*mapLogsResult,
 	debugger *debugger.Debugger,
 ) (
@@ -140,25 +258,21 @@ func pollCheap(
 
 	debugger.Debug(2, "polled event logs", zap.Int("len", len(logs)))
 
-	_, err = collectLogs(pollResults, logs)
-	if err != nil {
-		if errors.Is(err, errHashesDiffer) {
-			return pollResults, err
-		}
-
-		return nil, errors.Wrap(err, "collectLogs error")
-	}
+	// With verifyBlockHash, logs are only collected after they are verified against the headers
+	if !verifyBlockHash {
+		_, err = collectLogs(pollResults, logs)
+		if err != nil {
+			if errors.Is(err, errHashesDiffer) {
+				return pollResults, err
+			}
 
-	var targetBlocks []uint64
-	for n := fromBlock; n <= toBlock; n++ {
-		// PolicyFast only fetch headers for blocks in pollResults
-		if _, ok := pollResults[n]; !ok {
-			continue
+			return nil, errors.Wrap(err, "collectLogs error")
 		}
-
-		targetBlocks = append(targetBlocks, n)
 	}
 
+	// PolicyFast only fetch headers for blocks with logs
+	targetBlocks := blockNumbersOfLogs(fromBlock, toBlock, logs)
+
 	debugger.Debug(
 		3, "polling headers for targetBlocks",
 		zap.Uint64s("targetBlocks", targetBlocks),
@@ -169,6 +283,22 @@ func pollCheap(
 		return nil, errors.Wrap(superwatcher.ErrFetchError, "failed to get headers for resultBlocks")
 	}
 
+	if verifyBlockHash {
+		logs, err = verifyLogsByBlockHash(ctx, client, addresses, topics, logs, hashesOfHeaders(headers), debugger)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = collectLogs(pollResults, logs)
+		if err != nil {
+			if errors.Is(err, errHashesDiffer) {
+				return pollResults, err
+			}
+
+			return nil, errors.Wrap(err, "collectLogs error")
+		}
+	}
+
 	_, err = collectHeaders(pollResults, fromBlock, toBlock, headers)
 	if err != nil {
 		if errors.Is(err, errHashesDiffer) {
@@ -181,3 +311,40 @@ func pollCheap(
 	debugger.Debug(3, "pollCheap successful")
 	return pollResults, nil
 }
+
+// pollHeadersOnly fetches block headers for all blocks within range [fromBlock, toBlock]
+// and save them in pollResults, without fetching any event logs.
+func pollHeadersOnly(
+	ctx context.Context,
+	fromBlock uint64,
+	toBlock uint64,
+	client superwatcher.EthClient,
+	pollResults map[uint64]*mapLogsResult,
+	debugger *debugger.Debugger,
+) (
+	map[uint64]*mapLogsResult,
+	error,
+) {
+	blockNumbers := make([]uint64, 0, toBlock-fromBlock+1)
+	for n := fromBlock; n <= toBlock; n++ {
+		blockNumbers = append(blockNumbers, n)
+	}
+
+	headers, err := getHeadersByNumbers(ctx, client, blockNumbers)
+	if err != nil {
+		return nil, errors.Wrap(superwatcher.ErrFetchError, err.Error())
+	}
+
+	debugger.Debug(2, "polled headers", zap.Int("headers", len(headers)))
+
+	if len(blockNumbers) != len(headers) {
+		return nil, errors.Wrap(superwatcher.ErrFetchError, "headers and blockNumbers length not matched")
+	}
+
+	if _, err := collectHeaders(pollResults, fromBlock, toBlock, headers); err != nil {
+		return nil, errors.Wrap(err, "collectHeaders found error")
+	}
+
+	debugger.Debug(3, "pollHeadersOnly successful")
+	return pollResults, nil
+}
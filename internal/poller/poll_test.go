@@ -1,11 +1,16 @@
 package poller
 
 import (
+	"context"
 	"fmt"
+	"math/big"
+	"reflect"
 	"testing"
 
-	"github.com/soyart/gsl"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/soyart/gsl"
 	"github.com/pkg/errors"
 
 	"github.com/soyart/superwatcher"
@@ -31,6 +36,7 @@ func testPollNg(t *testing.T, caseNumber int) error {
 		superwatcher.PolicyFast,
 		superwatcher.PolicyNormal,
 		superwatcher.PolicyExpensive,
+		superwatcher.PolicyExpensiveBlock,
 	} {
 		tc := testlogs.TestCasesV1[caseNumber-1]
 		tracker := newTracker("testPollNg", 3)
@@ -105,6 +111,12 @@ func testPollNg(t *testing.T, caseNumber int) error {
 			t.Fatal("findReorg error")
 		}
 
+		if policy == superwatcher.PolicyExpensiveBlock {
+			if err := checkTransactions(pollResults); err != nil {
+				return errors.Wrapf(err, "bad transactions for policy %s", policy.String())
+			}
+		}
+
 		wasReorged := make(map[uint64]bool)
 		for k := range pollResults {
 			v, ok := pollResults[k]
@@ -166,3 +178,61 @@ func testPollNg(t *testing.T, caseNumber int) error {
 
 	return nil
 }
+
+// checkTransactions checks if every log in |pollResults| has its transaction in Block.Transactions
+func checkTransactions(pollResults map[uint64]*mapLogsResult) error {
+	for blockNumber, result := range pollResults {
+		txHashes := make(map[common.Hash]bool)
+		for _, tx := range result.Transactions {
+			txHashes[tx.Hash] = true
+		}
+
+		for _, log := range result.Logs {
+			if !txHashes[log.TxHash] {
+				return fmt.Errorf("block %d missing transaction %s", blockNumber, log.TxHash.String())
+			}
+		}
+	}
+
+	return nil
+}
+
+// newTestSim returns a ReorgSim with |param| over the chain built from |logs|, reorging with |events|.
+func newTestSim(
+	t *testing.T,
+	param reorgsim.Param,
+	logs map[uint64][]types.Log,
+	events ...reorgsim.ReorgEvent,
+) *reorgsim.ReorgSim {
+	t.Helper()
+
+	chain, reorgedChains := reorgsim.NewBlockChain(logs, events)
+	sim, err := reorgsim.NewReorgSim(param, events, chain, reorgedChains, "", 1)
+	if err != nil {
+		t.Fatal("cannot init ReorgSim", err.Error())
+	}
+
+	return sim
+}
+
+// forkSim forks |sim| to its next reorged chain before the next poll. ReorgSim only forks on FilterLogs calls
+// covering the reorg trigger, so if the poller's own FilterLogs call forked the chain, the headers fetched
+// concurrently by the same poll may or may not be from the reorged chain. forkSim calls FilterLogs directly instead,
+// once if the trigger was already seen by a previous poll, or twice if not (e.g. with PolicyHeaders).
+func forkSim(t *testing.T, sim *reorgsim.ReorgSim, fromBlock, toBlock uint64) {
+	t.Helper()
+
+	before := reflect.ValueOf(sim.Chain()).Pointer()
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(int64(fromBlock)), ToBlock: big.NewInt(int64(toBlock))}
+	for i := 0; i < 2; i++ {
+		if _, err := sim.FilterLogs(context.Background(), q); err != nil {
+			t.Fatal("unexpected FilterLogs error", err.Error())
+		}
+
+		if reflect.ValueOf(sim.Chain()).Pointer() != before {
+			return
+		}
+	}
+
+	t.Fatalf("ReorgSim did not fork within blocks %d-%d", fromBlock, toBlock)
+}
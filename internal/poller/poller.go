@@ -5,6 +5,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
 	"github.com/soyart/superwatcher"
 	"github.com/soyart/superwatcher/pkg/logger/debugger"
@@ -16,18 +17,37 @@ import (
 type poller struct {
 	sync.RWMutex
 
-	addresses []common.Address
-	topics    [][]common.Hash
+	addresses     []common.Address
+	topics        [][]common.Hash
+	subscriptions []superwatcher.Subscription
 
 	lastRecordedBlock uint64 // For clearing tracker if SetDoReorg(false) is called
 	filterRange       uint64
+	rangeLimit        uint64 // Max FilterLogs range known to work with the node, 0 means no limit
+	rangeLimitOK      uint64 // Number of consecutive polls that rangeLimit was not shrunk
+	addressChunkSize  uint64 // Max number of addresses per FilterLogs query, 0 means no limit
 	client            superwatcher.EthClient
 	doReorg           bool
 	doHeader          bool
+	doParentHash      bool
+	doReceipts        bool
+	doFinality        bool
+	doVerifyBlockHash bool
 	policy            superwatcher.Policy
 
-	tracker  *blockTracker
-	debugger *debugger.Debugger
+	store       superwatcher.TrackerStore // store persists tracker across restarts, may be nil
+	storeLoaded bool                      // true if tracker was already loaded from store
+
+	retryPolicy *superwatcher.RetryPolicy // retryPolicy is used to retry failed RPC calls, may be nil
+
+	discovery  superwatcher.DiscoveryFunc           // discovery maps polled logs to new addresses and topics, may be nil
+	discovered map[common.Address]discoveredAddress // discovered addresses, removed if their creating blocks are reorged
+
+	predicates []superwatcher.LogPredicate // predicates filter polled logs on the client side
+
+	tracker   *blockTracker
+	canonical *blockTracker // canonical stores hashes of all blocks seen, used if doParentHash is true
+	debugger  *debugger.Debugger
 }
 
 func New(
@@ -120,6 +140,82 @@ func (p *poller) DoHeader() bool {
 	return p.doHeader
 }
 
+// SetDoParentHash changes poller behavior regarding parent hash checks.
+// If |doParentHash| is true, the poller gets headers for all blocks in range, and checks each header's
+// parent hash against the previous canonical hash to find the exact fork point, even for blocks without logs.
+// If |doParentHash| is false, the poller discards its canonical hashes.
+func (p *poller) SetDoParentHash(doParentHash bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	if doParentHash == p.doParentHash {
+		return
+	}
+
+	if doParentHash {
+		p.debugger.Debug(1, "SetDoParentHash(true) called - creating canonical tracker")
+		p.canonical = newTracker("poller canonical", p.debugger.Level)
+	} else {
+		p.debugger.Debug(1, "SetDoParentHash(false) called - deleting canonical tracker")
+		p.canonical = nil
+	}
+
+	p.doParentHash = doParentHash
+}
+
+func (p *poller) DoParentHash() bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.doParentHash
+}
+
+// SetDoReceipts makes the poller fetch transaction receipts for blocks with logs in PollerResult.GoodBlocks.
+func (p *poller) SetDoReceipts(doReceipts bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.doReceipts = doReceipts
+}
+
+func (p *poller) DoReceipts() bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.doReceipts
+}
+
+// SetDoFinality makes the poller get the `safe` and `finalized` blocks to mark blocks with their finality.
+func (p *poller) SetDoFinality(doFinality bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.doFinality = doFinality
+}
+
+func (p *poller) DoFinality() bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.doFinality
+}
+
+// SetDoVerifyBlockHash makes the poller re-fetch logs with EIP-234 block hash queries for blocks
+// whose logs are inconsistent with their headers.
+func (p *poller) SetDoVerifyBlockHash(doVerifyBlockHash bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.doVerifyBlockHash = doVerifyBlockHash
+}
+
+func (p *poller) DoVerifyBlockHash() bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.doVerifyBlockHash
+}
+
 func (p *poller) Addresses() []common.Address {
 	p.RLock()
 	defer p.RUnlock()
@@ -172,26 +268,71 @@ func (p *poller) SetTopics(topics [][]common.Hash) {
 	p.topics = topics
 }
 
-func (p *poller) SetPolicy(level superwatcher.Policy) error {
-	// We don't have all the policies yet.
-	return errors.New("poller.SetPolicy not implemented")
-
-	// p.Lock()
-	// defer p.Unlock()
-	//
-	// // Remove all blocks from tracker with 0 logs if not PolicyExpensive,
-	// // because if these blocks are left in tracker, poller with level < PolicyExpensive
-	// // will see that these blocks are ones with missing logs and stamped as reorged = true.
-	// if p.policy < level {
-	// 	return errors.Wrapf(
-	// 		errDowngradeLevel,
-	// 		"cannot downgrade from %s (%d) to %s (%d)",
-	// 		p.policy.String(), p.policy, level.String(), level,
-	// 	)
-	// }
-	//
-	// p.policy = level
-	// return nil
+// SetPolicy changes poller policy on-the-fly. Before the new policy takes effect,
+// SetPolicy migrates p.tracker contents to match what the new policy would have tracked,
+// so that the switch does not produce false chain reorgs. See blockTracker.migratePolicy.
+func (p *poller) SetPolicy(policy superwatcher.Policy) error {
+	if policy > superwatcher.PolicyHeaders {
+		return errors.Wrapf(superwatcher.ErrBadPolicy, "unknown policy %d", policy)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if p.policy == policy {
+		return nil
+	}
+
+	p.debugger.Debug(
+		1, "SetPolicy called - changing policy",
+		zap.String("from", p.policy.String()),
+		zap.String("to", policy.String()),
+	)
+
+	if p.tracker != nil {
+		p.tracker.migratePolicy(p.policy, policy)
+	}
+
+	p.policy = policy
+	return nil
+}
+
+// FilterRangeLimit returns the maximum FilterLogs range (number of blocks) the poller knows to work
+// with the node. It returns 0 if the node has not yet rejected any FilterLogs range.
+func (p *poller) FilterRangeLimit() uint64 {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.rangeLimit
+}
+
+// updateRangeLimit saves range limit learned by |client| during a poll.
+// If the limit was not shrunk for growRangeLimitAfter consecutive polls,
+// the limit is doubled, so that the poller can go back to using larger ranges once the node allows it.
+// Once the limit has grown to cover the whole poll range |span|, the limit is removed.
+func (p *poller) updateRangeLimit(client *filterLogsSplitter, ok bool, span uint64) {
+	if client.shrunk {
+		p.rangeLimit = client.rangeLimit
+		p.rangeLimitOK = 0
+		return
+	}
+
+	if !ok || p.rangeLimit == 0 {
+		return
+	}
+
+	p.rangeLimitOK++
+	if p.rangeLimitOK < growRangeLimitAfter {
+		return
+	}
+
+	p.rangeLimit *= 2
+	p.rangeLimitOK = 0
+	if p.rangeLimit >= span {
+		p.rangeLimit = 0
+	}
+
+	p.debugger.Debug(1, "growing filterLogs range limit", zap.Uint64("newRangeLimit", p.rangeLimit))
 }
 
 func (p *poller) Policy() superwatcher.Policy {
@@ -0,0 +1,163 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+var allPolicies = []superwatcher.Policy{
+	superwatcher.PolicyFast,
+	superwatcher.PolicyNormal,
+	superwatcher.PolicyExpensive,
+	superwatcher.PolicyExpensiveBlock,
+	superwatcher.PolicyHeaders,
+}
+
+// TestSetPolicy switches poller policy between 2 polls over the same range,
+// with chain reorg occurring on the 2nd poll, and checks that the switch does not produce false reorgs.
+func TestSetPolicy(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	for _, from := range allPolicies {
+		for _, to := range allPolicies {
+			if from == to {
+				continue
+			}
+
+			client := newTestSim(t, tc.Param, logs, reorgEvent)
+
+			filterRange := tc.ToBlock - tc.FromBlock + 1
+			p := New(nil, nil, true, true, filterRange, client, 1, from)
+
+			// The 1st poll triggers the reorg event in ReorgSim, the 2nd poll sees the reorged chain.
+			if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+				t.Fatalf("[%s -> %s] unexpected error from 1st poll: %s", from, to, err.Error())
+			}
+
+			forkSim(t, client, tc.FromBlock, tc.ToBlock)
+
+			if err := p.SetPolicy(to); err != nil {
+				t.Fatalf("[%s -> %s] unexpected error from SetPolicy: %s", from, to, err.Error())
+			}
+			if policy := p.Policy(); policy != to {
+				t.Fatalf("[%s -> %s] unexpected policy after SetPolicy: %s", from, to, policy)
+			}
+
+			result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+			if err != nil {
+				t.Fatalf("[%s -> %s] unexpected error from 2nd poll: %s", from, to, err.Error())
+			}
+
+			reorged := make(map[uint64]bool)
+			for _, b := range result.ReorgedBlocks {
+				reorged[b.Number] = true
+
+				if b.Number < reorgEvent.ReorgBlock {
+					t.Errorf("[%s -> %s] block %d is before reorgBlock %d but was reorged", from, to, b.Number, reorgEvent.ReorgBlock)
+				}
+				// Blocks tracked with PolicyHeaders have no logs, but are still reorged
+				if from != superwatcher.PolicyHeaders && to <= superwatcher.PolicyNormal && len(b.Logs) == 0 {
+					t.Errorf("[%s -> %s] empty block %d was reorged", from, to, b.Number)
+				}
+				if to != superwatcher.PolicyExpensiveBlock && len(b.Transactions) != 0 {
+					t.Errorf("[%s -> %s] reorged block %d has transactions", from, to, b.Number)
+				}
+			}
+
+			for number := reorgEvent.ReorgBlock; number <= tc.ToBlock; number++ {
+				if len(logs[number]) == 0 {
+					continue
+				}
+
+				if !reorged[number] {
+					t.Errorf("[%s -> %s] block %d with logs was not reorged", from, to, number)
+				}
+			}
+		}
+	}
+}
+
+func TestSetPolicyBadPolicy(t *testing.T) {
+	p := New(nil, nil, true, true, 10, nil, 1, superwatcher.PolicyNormal)
+	if err := p.SetPolicy(superwatcher.PolicyHeaders + 1); err == nil {
+		t.Fatal("expecting error from unknown policy")
+	}
+
+	if policy := p.Policy(); policy != superwatcher.PolicyNormal {
+		t.Fatalf("unexpected policy %s", policy)
+	}
+}
+
+// noLogsClient fails the test if FilterLogs is called
+type noLogsClient struct {
+	superwatcher.EthClient
+
+	t *testing.T
+}
+
+func (c noLogsClient) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	c.t.Fatal("unexpected FilterLogs call with PolicyHeaders")
+	return nil, nil
+}
+
+// TestPolicyHeaders checks that PolicyHeaders emits every block in range without logs,
+// and still detects chain reorgs, without calling FilterLogs.
+func TestPolicyHeaders(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	sim := newTestSim(t, tc.Param, logs, reorgEvent)
+
+	filterRange := tc.ToBlock - tc.FromBlock + 1
+	p := New(nil, nil, true, true, filterRange, noLogsClient{EthClient: sim, t: t}, 1, superwatcher.PolicyHeaders)
+
+	// ReorgSim only forks its chain on FilterLogs calls, which PolicyHeaders never makes
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			forkSim(t, sim, tc.FromBlock, tc.ToBlock)
+		}
+
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatalf("unexpected error from poll %d: %s", i, err.Error())
+		}
+
+		if uint64(len(result.GoodBlocks)) != filterRange {
+			t.Fatalf("poll %d: expecting %d good blocks, got %d", i, filterRange, len(result.GoodBlocks))
+		}
+
+		for j, b := range result.GoodBlocks {
+			if b.Number != tc.FromBlock+uint64(j) || b.Logs != nil || b.Header == nil {
+				t.Fatalf("poll %d: unexpected good block %d with %d logs", i, b.Number, len(b.Logs))
+			}
+		}
+
+		if i == 0 {
+			if len(result.ReorgedBlocks) != 0 {
+				t.Fatalf("unexpected %d reorged blocks from 1st poll", len(result.ReorgedBlocks))
+			}
+
+			continue
+		}
+
+		if len(result.ReorgedBlocks) == 0 {
+			t.Fatal("expecting reorged blocks from 2nd poll")
+		}
+
+		for _, b := range result.ReorgedBlocks {
+			if b.Number < reorgEvent.ReorgBlock {
+				t.Fatalf("block %d is before reorgBlock %d but was reorged", b.Number, reorgEvent.ReorgBlock)
+			}
+		}
+	}
+}
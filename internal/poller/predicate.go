@@ -0,0 +1,66 @@
+package poller
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+func (p *poller) AddLogPredicates(predicates ...superwatcher.LogPredicate) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.predicates = append(p.predicates, predicates...)
+}
+
+// filterPredicates removes logs not matching all param.predicates from |pollResults|.
+// With PolicyFast and PolicyNormal, blocks left without logs are removed too, as if FilterLogs never returned
+// their logs. If such a block is in tracker, the block is then handled like any other tracker block missing
+// from the poll, i.e. its header is fetched, and it is only reported as reorged if its hash changed.
+// This is only correct because predicates are deterministic - a block whose hash did not change still
+// has the same logs, so it must still have the same matching logs.
+func filterPredicates(
+	param *param,
+	pollResults map[uint64]*mapLogsResult,
+	debugger *debugger.Debugger,
+) {
+	if len(param.predicates) == 0 {
+		return
+	}
+
+	var filtered int
+	for n, result := range pollResults {
+		logs := result.Logs[:0]
+		for _, log := range result.Logs {
+			if matchPredicates(param.predicates, log) {
+				logs = append(logs, log)
+				continue
+			}
+
+			filtered++
+		}
+
+		result.Logs = logs
+		if len(logs) == 0 {
+			result.Logs = nil
+
+			if param.policy <= superwatcher.PolicyNormal {
+				delete(pollResults, n)
+			}
+		}
+	}
+
+	debugger.Debug(3, "filtered logs with predicates", zap.Int("filteredLogs", filtered))
+}
+
+func matchPredicates(predicates []superwatcher.LogPredicate, log *types.Log) bool {
+	for _, predicate := range predicates {
+		if !predicate(log) {
+			return false
+		}
+	}
+
+	return true
+}
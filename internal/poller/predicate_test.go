@@ -0,0 +1,91 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// TestLogPredicates checks that only logs matching predicates are polled, and that blocks left
+// without matching logs do not break chain reorg detection.
+func TestLogPredicates(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	// Odd log indexes can't be expressed with FilterQuery
+	predicate := func(log *types.Log) bool { return log.Index%2 == 1 }
+
+	client := newTestSim(t, tc.Param, logs, reorgEvent)
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+	p.AddLogPredicates(predicate)
+
+	// Blocks with matching logs, before and after the reorg event
+	var expected, expectedReorged int
+	for n, blockLogs := range logs {
+		if n < tc.FromBlock || n > tc.ToBlock {
+			continue
+		}
+
+		for i := range blockLogs {
+			if predicate(&blockLogs[i]) {
+				expected++
+				if n >= reorgEvent.ReorgBlock {
+					expectedReorged++
+				}
+
+				break
+			}
+		}
+	}
+
+	// The 1st poll triggers the reorg event in ReorgSim, the 2nd poll sees the reorged chain.
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			forkSim(t, client, tc.FromBlock, tc.ToBlock)
+		}
+
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatalf("unexpected error from poll %d: %s", i, err.Error())
+		}
+
+		for _, b := range result.GoodBlocks {
+			if len(b.Logs) == 0 {
+				t.Fatalf("poll %d: unexpected good block %d without matching logs", i, b.Number)
+			}
+
+			for _, log := range b.Logs {
+				if !predicate(log) {
+					t.Fatalf("poll %d: unexpected log %d in block %d", i, log.Index, b.Number)
+				}
+			}
+		}
+
+		if i == 0 {
+			if len(result.GoodBlocks) != expected {
+				t.Fatalf("expecting %d good blocks, got %d", expected, len(result.GoodBlocks))
+			}
+
+			continue
+		}
+
+		if len(result.ReorgedBlocks) != expectedReorged {
+			t.Fatalf("expecting %d reorged blocks, got %d", expectedReorged, len(result.ReorgedBlocks))
+		}
+
+		for _, b := range result.ReorgedBlocks {
+			for _, log := range b.Logs {
+				if !predicate(log) {
+					t.Fatalf("unexpected log %d in reorged block %d", log.Index, b.Number)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/batch"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// blockReceiptsBatch is an intermediate type for marshaling and
+// unmarshaling rpc.BatchElem for method batch.MethodGetBlockReceipts.
+// It implements batch.Interface, so it can be passed to batch.CallBatch.
+type blockReceiptsBatch struct {
+	number   uint64           // filled by getReceipts
+	receipts []*types.Receipt // filled by blockReceiptsBatch.Unmarshal
+	err      error            // filled by blockReceiptsBatch.Unmarshal if the node returned error for this block
+}
+
+func (b *blockReceiptsBatch) Marshal() (rpc.BatchElem, error) {
+	return rpc.BatchElem{
+		Method: batch.MethodGetBlockReceipts,
+		Args: []interface{}{
+			hexutil.EncodeBig(big.NewInt(int64(b.number))),
+		},
+		Result: &[]*types.Receipt{},
+		Error:  nil,
+	}, nil
+}
+
+// Unmarshal does not return elem.Error, because the node may not support `eth_getBlockReceipts`,
+// in which case the caller can fall back to `eth_getTransactionReceipt`.
+func (b *blockReceiptsBatch) Unmarshal(elem rpc.BatchElem) error {
+	if elem.Error != nil {
+		b.err = elem.Error
+		return nil
+	}
+
+	switch receipts := elem.Result.(type) {
+	case *[]*types.Receipt:
+		b.receipts = *receipts
+
+	case []*types.Receipt:
+		// e.g. when the client is reorgsim.ReorgSim, which overwrites elem.Result with []*types.Receipt.
+		b.receipts = receipts
+
+	default:
+		return fmt.Errorf(
+			"unexpected result type for blockReceiptsBatch: %s",
+			reflect.TypeOf(elem.Result).String(),
+		)
+	}
+
+	return nil
+}
+
+// txReceiptBatch is an intermediate type for marshaling and
+// unmarshaling rpc.BatchElem for method batch.MethodGetTransactionReceipt.
+// It implements batch.Interface, so it can be passed to batch.CallBatch.
+type txReceiptBatch struct {
+	txHash  common.Hash    // filled by getReceipts
+	receipt *types.Receipt // filled by txReceiptBatch.Unmarshal
+}
+
+func (t *txReceiptBatch) Marshal() (rpc.BatchElem, error) {
+	return rpc.BatchElem{
+		Method: batch.MethodGetTransactionReceipt,
+		Args: []interface{}{
+			t.txHash,
+		},
+		Result: new(types.Receipt),
+		Error:  nil,
+	}, nil
+}
+
+func (t *txReceiptBatch) Unmarshal(elem rpc.BatchElem) error {
+	if elem.Error != nil {
+		return errors.Wrapf(elem.Error, "failed to get receipt for tx %s", hashStr(t.txHash))
+	}
+
+	receipt, ok := elem.Result.(*types.Receipt)
+	if !ok {
+		return fmt.Errorf(
+			"unexpected result type for txReceiptBatch: %s",
+			reflect.TypeOf(elem.Result).String(),
+		)
+	}
+
+	if receipt == nil || receipt.TxHash != t.txHash {
+		return fmt.Errorf("receipt not found for tx %s", hashStr(t.txHash))
+	}
+
+	t.receipt = receipt
+	return nil
+}
+
+// getReceipts gets receipts for transactions of the logs in |blocks|, grouped by block number and tx hash.
+// It first gets the receipts with `eth_getBlockReceipts` in batch, and for blocks whose receipts
+// could not be fetched that way, it falls back to `eth_getTransactionReceipt` for each transaction.
+// getReceipts returns errHashesDiffer if a receipt's block hash differs from its block's hash.
+func getReceipts(
+	ctx context.Context,
+	client superwatcher.EthClientRPC,
+	blocks []*superwatcher.Block,
+	debugger *debugger.Debugger,
+) (
+	map[uint64]map[common.Hash]*types.Receipt,
+	error,
+) {
+	elems := make([]batch.Interface, len(blocks))
+	for i, b := range blocks {
+		elems[i] = &blockReceiptsBatch{number: b.Number}
+	}
+
+	var fallbacks []*superwatcher.Block
+	results := make(map[uint64]map[common.Hash]*types.Receipt)
+
+	// Receipts are fetched in chunks, because |blocks| may exceed the node's batch limits.
+	if err := batch.CallBatchWithOptions(ctx, client, elems, batch.DefaultOptions); err != nil {
+		debugger.Debug(1, "failed to batch get block receipts, falling back to tx receipts", zap.Error(err))
+		fallbacks = blocks
+	} else {
+		for i, elem := range elems {
+			getReceiptsCall := elem.(*blockReceiptsBatch)
+			b := blocks[i]
+
+			if getReceiptsCall.err != nil {
+				debugger.Debug(
+					2, "failed to get block receipts, falling back to tx receipts",
+					zap.Uint64("blockNumber", b.Number),
+					zap.Error(getReceiptsCall.err),
+				)
+
+				fallbacks = append(fallbacks, b)
+				continue
+			}
+
+			receipts := make(map[common.Hash]*types.Receipt)
+			for _, receipt := range getReceiptsCall.receipts {
+				receipts[receipt.TxHash] = receipt
+			}
+
+			results[b.Number] = receiptsOfLogs(b, receipts)
+			if results[b.Number] == nil {
+				fallbacks = append(fallbacks, b)
+			}
+		}
+	}
+
+	if len(fallbacks) != 0 {
+		var txElems []batch.Interface
+		for _, b := range fallbacks {
+			for _, txHash := range txHashesOfLogs(b) {
+				txElems = append(txElems, &txReceiptBatch{txHash: txHash})
+			}
+		}
+
+		if err := batch.CallBatchWithOptions(ctx, client, txElems, batch.DefaultOptions); err != nil {
+			return nil, errors.Wrap(err, "failed to batch get tx receipts")
+		}
+
+		receipts := make(map[common.Hash]*types.Receipt)
+		for _, elem := range txElems {
+			getReceiptCall := elem.(*txReceiptBatch)
+			receipts[getReceiptCall.txHash] = getReceiptCall.receipt
+		}
+
+		for _, b := range fallbacks {
+			results[b.Number] = receiptsOfLogs(b, receipts)
+			if results[b.Number] == nil {
+				return nil, fmt.Errorf("missing tx receipts for block %d", b.Number)
+			}
+		}
+	}
+
+	for _, b := range blocks {
+		for _, receipt := range results[b.Number] {
+			if receipt.BlockHash != b.Hash {
+				return nil, errors.Wrapf(
+					errHashesDiffer, "block %d receipt blockHash differs from block hash: %s vs %s",
+					b.Number, hashStr(receipt.BlockHash), hashStr(b.Hash),
+				)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// receiptsOfLogs returns receipts for all transactions of b.Logs from |receipts|,
+// or nil if any of the transactions is missing from |receipts|.
+func receiptsOfLogs(
+	b *superwatcher.Block,
+	receipts map[common.Hash]*types.Receipt,
+) map[common.Hash]*types.Receipt {
+	txHashes := txHashesOfLogs(b)
+	results := make(map[common.Hash]*types.Receipt, len(txHashes))
+	for _, txHash := range txHashes {
+		receipt, ok := receipts[txHash]
+		if !ok || receipt == nil {
+			return nil
+		}
+
+		results[txHash] = receipt
+	}
+
+	return results
+}
+
+// txHashesOfLogs returns unique transaction hashes of b.Logs in order
+func txHashesOfLogs(b *superwatcher.Block) []common.Hash {
+	seen := make(map[common.Hash]bool)
+
+	var txHashes []common.Hash
+	for _, log := range b.Logs {
+		if seen[log.TxHash] {
+			continue
+		}
+
+		seen[log.TxHash] = true
+		txHashes = append(txHashes, log.TxHash)
+	}
+
+	return txHashes
+}
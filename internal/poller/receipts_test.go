@@ -0,0 +1,85 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// TestPollReceipts checks that with doReceipts, the poller attaches receipts for all transactions of
+// the logs in GoodBlocks, both with `eth_getBlockReceipts` and with the `eth_getTransactionReceipt` fallback.
+// It also checks that receipts of reorged blocks are dropped.
+func TestPollReceipts(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	for _, noBlockReceipts := range []bool{false, true} {
+		param := tc.Param
+		param.NoBlockReceipts = noBlockReceipts
+
+		client := newTestSim(t, param, logs, tc.Events[0])
+
+		p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+		p.SetDoReceipts(true)
+
+		// The 1st poll triggers the reorg event in ReorgSim, the 2nd poll sees the reorged chain.
+		for i := 0; i < 2; i++ {
+			if i == 1 {
+				forkSim(t, client, tc.FromBlock, tc.ToBlock)
+			}
+
+			result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+			if err != nil {
+				t.Fatalf("[noBlockReceipts %v] unexpected error from poll %d: %s", noBlockReceipts, i, err.Error())
+			}
+
+			checkReceipts(t, result.GoodBlocks)
+
+			for _, b := range result.ReorgedBlocks {
+				if b.Receipts != nil {
+					t.Fatalf("[noBlockReceipts %v] reorged block %d has receipts", noBlockReceipts, b.Number)
+				}
+			}
+		}
+	}
+}
+
+func checkReceipts(t *testing.T, blocks []*superwatcher.Block) {
+	t.Helper()
+
+	for _, b := range blocks {
+		if len(b.Logs) == 0 {
+			continue
+		}
+
+		if b.Receipts == nil {
+			t.Fatalf("block %d has logs but no receipts", b.Number)
+		}
+
+		for _, log := range b.Logs {
+			receipt, ok := b.Receipts[log.TxHash]
+			if !ok {
+				t.Fatalf("missing receipt for tx %s in block %d", log.TxHash.String(), b.Number)
+			}
+
+			if receipt.BlockHash != b.Hash {
+				t.Fatalf("receipt blockHash differs from block %d hash", b.Number)
+			}
+
+			var found bool
+			for _, receiptLog := range receipt.Logs {
+				if receiptLog.Index == log.Index {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				t.Fatalf("log %d of block %d not in receipt logs", log.Index, b.Number)
+			}
+		}
+	}
+}
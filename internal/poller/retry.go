@@ -0,0 +1,136 @@
+package poller
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// SetRetryPolicy sets the policy used to retry failed RPC calls during Poll. Nil means no retries.
+func (p *poller) SetRetryPolicy(policy *superwatcher.RetryPolicy) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.retryPolicy = policy
+}
+
+// retryClient wraps superwatcher.EthClient, and retries failed calls with policy.
+// FilterLogs errors caused by too large ranges are not retried, so that filterLogsSplitter can split the range.
+type retryClient struct {
+	superwatcher.EthClient
+	policy   *superwatcher.RetryPolicy
+	debugger *debugger.Debugger
+}
+
+// withRetry returns |client| wrapped with retryClient, or |client| if |policy| is nil.
+func withRetry(
+	client superwatcher.EthClient,
+	policy *superwatcher.RetryPolicy,
+	debugger *debugger.Debugger,
+) superwatcher.EthClient {
+	if policy == nil {
+		return client
+	}
+
+	return &retryClient{
+		EthClient: client,
+		policy:    policy,
+		debugger:  debugger,
+	}
+}
+
+func (c *retryClient) do(ctx context.Context, method string, f func() error) error {
+	var attempts int
+	err := c.policy.Do(ctx, func() error {
+		attempts++
+		if attempts > 1 {
+			c.debugger.Debug(2, "retrying RPC call", zap.String("method", method), zap.Int("attempt", attempts))
+		}
+
+		return f()
+	})
+
+	if err != nil && attempts > 1 {
+		c.debugger.Warn(1, "RPC call failed after retries", zap.String("method", method), zap.Int("attempts", attempts), zap.Error(err))
+	}
+
+	return err
+}
+
+func (c *retryClient) BlockNumber(ctx context.Context) (uint64, error) {
+	var number uint64
+	err := c.do(ctx, "BlockNumber", func() error {
+		var err error
+		number, err = c.EthClient.BlockNumber(ctx)
+		return err
+	})
+
+	return number, err
+}
+
+func (c *retryClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	var rangeTooLarge error
+	err := c.do(ctx, "FilterLogs", func() error {
+		var err error
+		logs, err = c.EthClient.FilterLogs(ctx, q)
+		if isRangeTooLarge(err) {
+			rangeTooLarge = err
+			return nil
+		}
+
+		return err
+	})
+
+	if rangeTooLarge != nil {
+		return nil, rangeTooLarge
+	}
+
+	return logs, err
+}
+
+func (c *retryClient) HeaderByNumber(ctx context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
+	var header superwatcher.BlockHeader
+	err := c.do(ctx, "HeaderByNumber", func() error {
+		var err error
+		header, err = c.EthClient.HeaderByNumber(ctx, number)
+		return err
+	})
+
+	return header, err
+}
+
+func (c *retryClient) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	var number uint64
+	err := c.do(ctx, "SafeBlockNumber", func() error {
+		var err error
+		number, err = c.EthClient.SafeBlockNumber(ctx)
+		return err
+	})
+
+	return number, err
+}
+
+func (c *retryClient) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	var number uint64
+	err := c.do(ctx, "FinalizedBlockNumber", func() error {
+		var err error
+		number, err = c.EthClient.FinalizedBlockNumber(ctx)
+		return err
+	})
+
+	return number, err
+}
+
+func (c *retryClient) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	return c.do(ctx, "BatchCallContext", func() error {
+		return c.EthClient.BatchCallContext(ctx, elems)
+	})
+}
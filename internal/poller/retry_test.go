@@ -0,0 +1,67 @@
+package poller
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+// flakyClient mocks a node that fails the first |failures| FilterLogs calls
+type flakyClient struct {
+	superwatcher.EthClient
+	failures int
+	calls    int
+}
+
+func (c *flakyClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.calls++
+	if c.calls <= c.failures {
+		return nil, errors.New("connection reset by peer")
+	}
+
+	return c.EthClient.FilterLogs(ctx, q)
+}
+
+// TestRetryClient checks that Poll retries transient RPC errors with its RetryPolicy,
+// but does not retry FilterLogs errors caused by too large ranges.
+func TestRetryClient(t *testing.T) {
+	sim, tc := newNoReorgSim(t)
+	policy := &superwatcher.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	// Poll succeeds after 2 transient failures
+	client := &flakyClient{EthClient: sim, failures: 2}
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+	p.SetRetryPolicy(policy)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from Poll with retries", err.Error())
+	}
+
+	// Poll fails once the attempts are exhausted
+	client = &flakyClient{EthClient: sim, failures: 3}
+	p = New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+	p.SetRetryPolicy(policy)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); !errors.Is(err, superwatcher.ErrFetchError) {
+		t.Fatalf("expecting ErrFetchError after retries, got %v", err)
+	}
+	if client.calls != policy.MaxAttempts {
+		t.Fatalf("expecting %d FilterLogs calls, got %d", policy.MaxAttempts, client.calls)
+	}
+
+	// Range errors are not retried, so that the range can be split
+	limited := &limitedClient{EthClient: sim, maxRange: 10}
+	retry := withRetry(limited, policy, p.(*poller).debugger)
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(int64(tc.FromBlock)), ToBlock: big.NewInt(int64(tc.FromBlock + 20))}
+	if _, err := retry.FilterLogs(context.Background(), q); !isRangeTooLarge(err) {
+		t.Fatalf("expecting range error, got %v", err)
+	}
+	if limited.calls != 1 {
+		t.Fatalf("expecting 1 FilterLogs call for range error, got %d", limited.calls)
+	}
+}
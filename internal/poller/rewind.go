@@ -0,0 +1,89 @@
+package poller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+)
+
+// Rewind removes blocks from |fromBlock| onward from the tracker, and returns blocks from |fromBlock| to |toBlock|
+// as ReorgedBlocks, with LastGoodBlock set to the block before |fromBlock|. Tracked blocks are returned as tracked,
+// while blocks older than the tracker (or all blocks if p.doReorg is false) are polled again like Backfill.
+// Discovered addresses created in the rewound blocks are removed, and will be rediscovered when the blocks are polled again.
+func (p *poller) Rewind(
+	ctx context.Context,
+	fromBlock uint64,
+	toBlock uint64,
+) (
+	*superwatcher.PollerResult,
+	error,
+) {
+	// Hold the lock for the whole rewind, so that a concurrent Poll cannot change the tracker
+	// between computing the tracked range and removing the rewound blocks.
+	p.Lock()
+	defer p.Unlock()
+
+	if err := p.loadTracker(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to load tracker from store")
+	}
+
+	// Blocks before the oldest tracked block are no longer tracked, and must be polled again
+	trackedFrom := toBlock + 1
+	if p.tracker != nil {
+		if oldest := p.tracker.sortedSet.PeekMin(); oldest != nil && uint64(oldest.Score()) < trackedFrom {
+			trackedFrom = uint64(oldest.Score())
+		}
+	}
+
+	result := &superwatcher.PollerResult{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Rewind:    true,
+	}
+
+	if fromBlock < trackedFrom {
+		backfilled, err := p.newBackfiller(fromBlock, trackedFrom-1).backfill(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to poll untracked blocks %d-%d", fromBlock, trackedFrom-1)
+		}
+
+		result.ReorgedBlocks = backfilled.GoodBlocks
+	}
+
+	if p.tracker != nil {
+		for _, b := range p.tracker.blocks() {
+			if b.Number < fromBlock {
+				continue
+			}
+
+			if b.Number <= toBlock {
+				result.ReorgedBlocks = append(result.ReorgedBlocks, b)
+			}
+
+			if err := p.tracker.removeBlock(b.Number); err != nil {
+				return nil, errors.Wrapf(superwatcher.ErrSuperwatcherBug, "failed to remove rewound block: %s", err.Error())
+			}
+		}
+	}
+
+	p.undiscover(result.ReorgedBlocks)
+
+	if fromBlock != 0 {
+		result.LastGoodBlock = fromBlock - 1
+	}
+
+	p.lastRecordedBlock = result.LastGoodBlock
+	p.saveTracker(ctx)
+
+	p.debugger.Debug(
+		1, "rewound blocks",
+		zap.Uint64("fromBlock", fromBlock),
+		zap.Uint64("toBlock", toBlock),
+		zap.Int("reorgedBlocks", len(result.ReorgedBlocks)),
+	)
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+func TestRewind(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+
+	for _, doReorg := range []bool{true, false} {
+		logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+		sim := newTestSim(t, tc.Param, logs)
+
+		p := New(nil, nil, doReorg, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+
+		polled, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatal("unexpected poll error", err.Error())
+		}
+
+		// Rewind the second half of the range
+		fromBlock := tc.FromBlock + (tc.ToBlock-tc.FromBlock)/2
+		var expected []*superwatcher.Block
+		for _, b := range polled.GoodBlocks {
+			if b.Number >= fromBlock {
+				expected = append(expected, b)
+			}
+		}
+
+		if len(expected) == 0 {
+			t.Fatal("bad test case: no blocks to rewind")
+		}
+
+		result, err := p.Rewind(context.Background(), fromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatal("unexpected rewind error", err.Error())
+		}
+
+		if !result.Rewind || result.LastGoodBlock != fromBlock-1 || len(result.GoodBlocks) != 0 {
+			t.Fatalf("[doReorg %v] unexpected rewind result %+v", doReorg, result)
+		}
+
+		if len(result.ReorgedBlocks) != len(expected) {
+			t.Fatalf("[doReorg %v] expecting %d rewound blocks, got %d", doReorg, len(expected), len(result.ReorgedBlocks))
+		}
+
+		for i, b := range expected {
+			if rewound := result.ReorgedBlocks[i]; rewound.Number != b.Number || rewound.Hash != b.Hash || len(rewound.Logs) != len(b.Logs) {
+				t.Fatalf("[doReorg %v] unexpected rewound block %d, expecting block %d", doReorg, rewound.Number, b.Number)
+			}
+		}
+
+		if !doReorg {
+			continue
+		}
+
+		for _, b := range p.(*poller).tracker.blocks() {
+			if b.Number >= fromBlock {
+				t.Fatalf("rewound block %d still tracked", b.Number)
+			}
+		}
+	}
+}
+
+// lockCheckClient calls check on every FilterLogs call
+type lockCheckClient struct {
+	superwatcher.EthClient
+	check func()
+}
+
+func (c *lockCheckClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.check()
+	return c.EthClient.FilterLogs(ctx, q)
+}
+
+// TestRewindLocked checks that the poller stays locked while Rewind polls untracked blocks,
+// so that a concurrent Poll cannot change the tracker in the middle of Rewind.
+func TestRewindLocked(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	client := &lockCheckClient{EthClient: newTestSim(t, tc.Param, reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...))}
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+
+	// Only the 2nd half of the range is tracked, so the 1st half is polled again by Rewind
+	midBlock := tc.FromBlock + (tc.ToBlock-tc.FromBlock)/2
+	client.check = func() {}
+	if _, err := p.Poll(context.Background(), midBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected poll error", err.Error())
+	}
+
+	var checked bool
+	client.check = func() {
+		checked = true
+		if p.(*poller).TryRLock() {
+			p.(*poller).RUnlock()
+			t.Error("poller was not locked while polling untracked blocks in Rewind")
+		}
+	}
+
+	if _, err := p.Rewind(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected rewind error", err.Error())
+	}
+	if !checked {
+		t.Fatal("bad test case: Rewind did not poll untracked blocks")
+	}
+}
@@ -0,0 +1,160 @@
+package poller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/soyart/gsl"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+// AddSubscription registers |sub| as a named filter query.
+// It returns ErrBadSubscription if sub.ID is empty, reserved, or already registered.
+func (p *poller) AddSubscription(sub superwatcher.Subscription) error {
+	if sub.ID == "" || sub.ID == superwatcher.DefaultSubscriptionID {
+		return errors.Wrapf(superwatcher.ErrBadSubscription, "bad subscription ID \"%s\"", sub.ID)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	for i := range p.subscriptions {
+		if p.subscriptions[i].ID == sub.ID {
+			return errors.Wrapf(superwatcher.ErrBadSubscription, "duplicate subscription ID \"%s\"", sub.ID)
+		}
+	}
+
+	p.subscriptions = append(p.subscriptions, sub)
+	return nil
+}
+
+// RemoveSubscription removes subscription with ID |id|.
+// It returns ErrBadSubscription if there is no such subscription.
+func (p *poller) RemoveSubscription(id string) error {
+	p.Lock()
+	defer p.Unlock()
+
+	for i := range p.subscriptions {
+		if p.subscriptions[i].ID == id {
+			p.subscriptions = append(p.subscriptions[:i], p.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.Wrapf(superwatcher.ErrBadSubscription, "subscription ID \"%s\" not found", id)
+}
+
+func (p *poller) Subscriptions() []superwatcher.Subscription {
+	p.RLock()
+	defer p.RUnlock()
+
+	subs := make([]superwatcher.Subscription, len(p.subscriptions))
+	copy(subs, p.subscriptions)
+
+	return subs
+}
+
+// querySubscriptions returns subscriptions to be queried by subscriptionsClient, or nil if there's none registered.
+// If p.addresses or p.topics is not empty, they are queried as subscription superwatcher.DefaultSubscriptionID.
+func (p *poller) querySubscriptions() []superwatcher.Subscription {
+	if len(p.subscriptions) == 0 {
+		return nil
+	}
+
+	var subs []superwatcher.Subscription
+	if len(p.addresses) != 0 || len(p.topics) != 0 {
+		subs = append(subs, superwatcher.Subscription{
+			ID:        superwatcher.DefaultSubscriptionID,
+			Addresses: p.addresses,
+			Topics:    p.topics,
+		})
+	}
+
+	return append(subs, p.subscriptions...)
+}
+
+// logKey identifies a log in subscriptionsClient
+type logKey struct {
+	blockHash common.Hash
+	index     uint
+}
+
+// subscriptionsClient wraps superwatcher.EthClient, and replaces the addresses and topics
+// of each FilterLogs call with those of each subscription, 1 call per subscription.
+// The logs from all subscriptions are deduplicated and merged in order, so the caller sees 1 big FilterLogs call,
+// and the IDs of subscriptions each log matched are saved to tags. The same logs may be filtered again
+// in the same poll, e.g. by verifyLogsByBlockHash, so each ID is only saved once per log.
+type subscriptionsClient struct {
+	superwatcher.EthClient
+	subscriptions []superwatcher.Subscription
+	tags          map[logKey][]string // filled by FilterLogs
+	debugger      *debugger.Debugger
+}
+
+func (c *subscriptionsClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if c.tags == nil {
+		c.tags = make(map[logKey][]string)
+	}
+
+	var logs []types.Log
+	seen := make(map[logKey]bool)
+	for _, sub := range c.subscriptions {
+		subQuery := q
+		subQuery.Addresses = sub.Addresses
+		subQuery.Topics = sub.Topics
+
+		subLogs, err := c.EthClient.FilterLogs(ctx, subQuery)
+		if err != nil {
+			return nil, errors.Wrapf(err, "FilterLogs failed for subscription %s", sub.ID)
+		}
+
+		c.debugger.Debug(
+			3, "filtered logs for subscription",
+			zap.String("subscription", sub.ID),
+			zap.Int("logs", len(subLogs)),
+		)
+
+		for _, log := range subLogs {
+			k := logKey{blockHash: log.BlockHash, index: log.Index}
+			if !seen[k] {
+				seen[k] = true
+				logs = append(logs, log)
+			}
+
+			if !gsl.Contains(c.tags[k], sub.ID) {
+				c.tags[k] = append(c.tags[k], sub.ID)
+			}
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+
+		return logs[i].Index < logs[j].Index
+	})
+
+	return logs, nil
+}
+
+// tagLogs sets Block.LogSubscriptions for all blocks in |pollResults| from |tags|
+func tagLogs(pollResults map[uint64]*mapLogsResult, tags map[logKey][]string) {
+	for _, result := range pollResults {
+		if len(result.Logs) == 0 {
+			continue
+		}
+
+		result.LogSubscriptions = make(map[uint][]string, len(result.Logs))
+		for _, log := range result.Logs {
+			result.LogSubscriptions[log.Index] = tags[logKey{blockHash: log.BlockHash, index: log.Index}]
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package poller
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+func TestSubscriptions(t *testing.T) {
+	sim, tc := newNoReorgSim(t)
+
+	allLogs, err := sim.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: big.NewInt(int64(tc.FromBlock)),
+		ToBlock:   big.NewInt(int64(tc.ToBlock)),
+	})
+	if err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	// Pick 2 different addresses from the logs
+	addrA := allLogs[0].Address
+	var addrB common.Address
+	for _, log := range allLogs {
+		if log.Address != addrA {
+			addrB = log.Address
+			break
+		}
+	}
+	if addrB == (common.Address{}) {
+		t.Fatal("test logs only have 1 address")
+	}
+
+	expected := make(map[common.Address]int)
+	for _, log := range allLogs {
+		expected[log.Address]++
+	}
+
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, sim, 1, superwatcher.PolicyNormal)
+	for _, sub := range []superwatcher.Subscription{
+		{ID: "a", Addresses: []common.Address{addrA}},
+		{ID: "b", Addresses: []common.Address{addrB}},
+		{ID: "ab", Addresses: []common.Address{addrA, addrB}},
+	} {
+		if err := p.AddSubscription(sub); err != nil {
+			t.Fatal("unexpected AddSubscription error", err.Error())
+		}
+	}
+
+	result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected Poll error", err.Error())
+	}
+
+	expectedTags := map[common.Address][]string{
+		addrA: {"a", "ab"},
+		addrB: {"b", "ab"},
+	}
+
+	actual := make(map[common.Address]int)
+	for _, b := range result.GoodBlocks {
+		for _, log := range b.Logs {
+			actual[log.Address]++
+
+			tags, want := b.SubscriptionsOf(log), expectedTags[log.Address]
+			if len(tags) != len(want) {
+				t.Fatalf("unexpected tags for log %d in block %d: %v", log.Index, b.Number, tags)
+			}
+			for i := range tags {
+				if tags[i] != want[i] {
+					t.Fatalf("unexpected tags for log %d in block %d: %v", log.Index, b.Number, tags)
+				}
+			}
+		}
+	}
+
+	if len(actual) != 2 || actual[addrA] != expected[addrA] || actual[addrB] != expected[addrB] {
+		t.Fatalf("unexpected logs count by address: expecting %d and %d, got %v", expected[addrA], expected[addrB], actual)
+	}
+}
+
+func TestSubscriptionsBadSubscription(t *testing.T) {
+	p := New(nil, nil, true, true, 10, nil, 1, superwatcher.PolicyNormal)
+
+	if err := p.AddSubscription(superwatcher.Subscription{ID: "ens"}); err != nil {
+		t.Fatal("unexpected AddSubscription error", err.Error())
+	}
+
+	for _, id := range []string{"", superwatcher.DefaultSubscriptionID, "ens"} {
+		if err := p.AddSubscription(superwatcher.Subscription{ID: id}); !errors.Is(err, superwatcher.ErrBadSubscription) {
+			t.Fatalf("expecting ErrBadSubscription for ID \"%s\", got %v", id, err)
+		}
+	}
+
+	if err := p.RemoveSubscription("ens"); err != nil {
+		t.Fatal("unexpected RemoveSubscription error", err.Error())
+	}
+	if err := p.RemoveSubscription("ens"); !errors.Is(err, superwatcher.ErrBadSubscription) {
+		t.Fatalf("expecting ErrBadSubscription when removing unknown subscription, got %v", err)
+	}
+
+	if subs := p.Subscriptions(); len(subs) != 0 {
+		t.Fatalf("expecting no subscriptions, got %d", len(subs))
+	}
+}
+
+// TestSubscriptionsClientDedup checks that logs matched by overlapping subscriptions are only returned once,
+// in order of block numbers and log indexes
+func TestSubscriptionsClientDedup(t *testing.T) {
+	sim, tc := newNoReorgSim(t)
+	q := ethereum.FilterQuery{
+		FromBlock: big.NewInt(int64(tc.FromBlock)),
+		ToBlock:   big.NewInt(int64(tc.ToBlock)),
+	}
+
+	expected, err := sim.FilterLogs(context.Background(), q)
+	if err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	client := &subscriptionsClient{
+		EthClient:     sim,
+		subscriptions: []superwatcher.Subscription{{ID: "all1"}, {ID: "all2"}},
+		debugger:      debugger.NewDebugger("testSubscriptionsClient", 1),
+	}
+
+	logs, err := client.FilterLogs(context.Background(), q)
+	if err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	if len(logs) != len(expected) {
+		t.Fatalf("expecting %d logs, got %d", len(expected), len(logs))
+	}
+
+	seen := make(map[logKey]bool)
+	for i, log := range logs {
+		seen[logKey{blockHash: log.BlockHash, index: log.Index}] = true
+		if i == 0 {
+			continue
+		}
+
+		if prev := logs[i-1]; prev.BlockNumber > log.BlockNumber || (prev.BlockNumber == log.BlockNumber && prev.Index > log.Index) {
+			t.Fatalf("logs[%d] not in order", i)
+		}
+	}
+
+	for _, log := range expected {
+		if !seen[logKey{blockHash: log.BlockHash, index: log.Index}] {
+			t.Fatalf("missing log %d in block %d", log.Index, log.BlockNumber)
+		}
+	}
+}
+
+// TestSubscriptionsVerifyBlockHash checks that logs re-fetched by verifyLogsByBlockHash
+// are returned again, and are not tagged twice with the same subscription.
+func TestSubscriptionsVerifyBlockHash(t *testing.T) {
+	sim, tc := newNoReorgSim(t)
+
+	allLogs, err := sim.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: big.NewInt(int64(tc.FromBlock)),
+		ToBlock:   big.NewInt(int64(tc.ToBlock)),
+	})
+	if err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	// Make the 1st log of a block with more than 1 log inconsistent with its header,
+	// so that the whole block is re-fetched while its other logs were already tagged.
+	expected := make(map[uint64]int)
+	for _, log := range allLogs {
+		expected[log.BlockNumber]++
+	}
+
+	staleLogs := make([]types.Log, len(allLogs))
+	copy(staleLogs, allLogs)
+
+	var staleBlock uint64
+	for i, log := range staleLogs {
+		if expected[log.BlockNumber] > 1 {
+			staleBlock = log.BlockNumber
+			staleLogs[i].BlockHash = common.BigToHash(big.NewInt(69))
+			break
+		}
+	}
+	if staleBlock == 0 {
+		t.Fatal("bad test case: no block with more than 1 log")
+	}
+
+	client := &straddlingClient{ReorgSim: sim, staleLogs: staleLogs}
+	p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+	p.SetDoVerifyBlockHash(true)
+	for _, sub := range []superwatcher.Subscription{{ID: "all1"}, {ID: "all2"}} {
+		if err := p.AddSubscription(sub); err != nil {
+			t.Fatal("unexpected AddSubscription error", err.Error())
+		}
+	}
+
+	result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected Poll error", err.Error())
+	}
+
+	for _, b := range result.GoodBlocks {
+		if len(b.Logs) != expected[b.Number] {
+			t.Fatalf("expecting %d logs in block %d, got %d", expected[b.Number], b.Number, len(b.Logs))
+		}
+
+		for _, log := range b.Logs {
+			if tags := b.SubscriptionsOf(log); len(tags) != 2 || tags[0] != "all1" || tags[1] != "all2" {
+				t.Fatalf("unexpected tags for log %d in block %d: %v", log.Index, b.Number, tags)
+			}
+		}
+	}
+}
@@ -22,15 +22,24 @@ type blockTracker struct {
 	sortedSet *sortedset.SortedSet
 	user      string
 	debugger  *debugger.Debugger
+
+	// headersOnly marks blocks tracked with PolicyHeaders before the policy was changed.
+	// Their logs are unknown, so only their hashes are compared until they are tracked again.
+	headersOnly map[uint64]bool
+
+	// verifiedUntil is the highest finalized block verified and removed by verifyFinalized.
+	// Finalized blocks up to verifiedUntil are never tracked again.
+	verifiedUntil uint64
 }
 
 func newTracker(user string, debugLevel uint8) *blockTracker {
 	key := fmt.Sprintf("blockTracker for %s", user)
 
 	return &blockTracker{
-		sortedSet: sortedset.New(),
-		user:      user,
-		debugger:  debugger.NewDebugger(key, debugLevel),
+		sortedSet:   sortedset.New(),
+		user:        user,
+		debugger:    debugger.NewDebugger(key, debugLevel),
+		headersOnly: make(map[uint64]bool),
 	}
 }
 
@@ -46,6 +55,7 @@ func (t *blockTracker) addTrackerBlock(b *superwatcher.Block) {
 
 	k := strconv.FormatUint(b.Number, 10)
 	t.sortedSet.AddOrUpdate(k, sortedset.SCORE(b.Number), b)
+	delete(t.headersOnly, b.Number)
 }
 
 // getTrackerBlock returns `*Block` from t with key |blockNumber|
@@ -71,9 +81,21 @@ func (t *blockTracker) removeBlock(blockNumber uint64) error {
 		return fmt.Errorf("node key %s was not in set", k)
 	}
 
+	delete(t.headersOnly, blockNumber)
 	return nil
 }
 
+// markHeadersOnly marks block |blockNumber| as tracked with PolicyHeaders, see isHeadersOnly.
+func (t *blockTracker) markHeadersOnly(blockNumber uint64) {
+	t.headersOnly[blockNumber] = true
+}
+
+// isHeadersOnly returns true if block |blockNumber| was tracked with PolicyHeaders
+// and has not been tracked again since the policy was changed.
+func (t *blockTracker) isHeadersOnly(blockNumber uint64) bool {
+	return t.headersOnly[blockNumber]
+}
+
 // clearUntil removes `*Block` in t from left to right.
 func (t *blockTracker) clearUntil(blockNumber uint64) {
 	for {
@@ -83,9 +105,86 @@ func (t *blockTracker) clearUntil(blockNumber uint64) {
 		}
 
 		t.sortedSet.PopMin()
+		delete(t.headersOnly, uint64(oldest.Score()))
 	}
 }
 
+// blocks returns all `*Block` in t, sorted by block number
+func (t *blockTracker) blocks() []*superwatcher.Block {
+	nodes := t.sortedSet.GetByRankRange(1, -1, false)
+	blocks := make([]*superwatcher.Block, len(nodes))
+	for i, node := range nodes {
+		b, ok := node.Value.(*superwatcher.Block)
+		if !ok {
+			logger.Panic(fmt.Sprintf("type assertion failed - expecting *Block, found %s", reflect.TypeOf(node.Value)))
+		}
+
+		blocks[i] = b
+	}
+
+	return blocks
+}
+
 func (t *blockTracker) Len() int {
 	return t.sortedSet.GetCount()
 }
+
+// migratePolicy migrates tracker contents when poller policy is changed from |from| to |to|,
+// so that the next poll with policy |to| sees the tracker as if it had been using |to| all along.
+//
+// When downgrading to PolicyNormal or PolicyFast, blocks with 0 logs are removed,
+// because the cheaper policies will see these blocks as blocks with missing logs,
+// and will report them as ReorgedBlocks once their hashes change.
+// When leaving PolicyExpensiveBlock, transactions are dropped from the tracked blocks,
+// because they will never be refreshed again by other policies.
+//
+// When switching to PolicyHeaders, logs, receipts and transactions are dropped from the tracked blocks,
+// since PolicyHeaders only compares block hashes. When leaving PolicyHeaders, the tracked blocks
+// are kept and marked as headersOnly, because their logs are unknown: findReorg will only compare
+// their hashes until they are tracked again with the new policy.
+//
+// Upgrading does not need any other migration, since the more expensive policies
+// will start tracking all blocks (including empty ones) from the next poll on.
+func (t *blockTracker) migratePolicy(from, to superwatcher.Policy) {
+	if from == to {
+		return
+	}
+
+	var removed []uint64
+	for _, node := range t.sortedSet.GetByRankRange(1, -1, false) {
+		b, ok := node.Value.(*superwatcher.Block)
+		if !ok {
+			logger.Panic(fmt.Sprintf("type assertion failed - expecting *Block, found %s", reflect.TypeOf(node.Value)))
+		}
+
+		switch {
+		case to == superwatcher.PolicyHeaders:
+			b.Logs = nil
+			b.Receipts = nil
+			b.Transactions = nil
+			delete(t.headersOnly, b.Number)
+
+		case from == superwatcher.PolicyHeaders:
+			t.markHeadersOnly(b.Number)
+
+		default:
+			if from == superwatcher.PolicyExpensiveBlock {
+				b.Transactions = nil
+			}
+
+			// headersOnly blocks have unknown logs, so they are kept until tracked again
+			if from > to && to <= superwatcher.PolicyNormal && len(b.Logs) == 0 && !t.isHeadersOnly(b.Number) {
+				t.sortedSet.Remove(node.Key())
+				removed = append(removed, b.Number)
+			}
+		}
+	}
+
+	t.debugger.Debug(
+		1, "migrated tracker policy",
+		zap.String("from", from.String()),
+		zap.String("to", to.String()),
+		zap.Uint64s("removedEmptyBlocks", removed),
+		zap.Int("headersOnlyBlocks", len(t.headersOnly)),
+	)
+}
@@ -0,0 +1,82 @@
+package poller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+)
+
+// SetTrackerStore sets the store used to persist p.tracker. The tracker is loaded from |store|
+// on the next call to Poll, so that blocks reorged while the poller was down are reported in PollerResult.ReorgedBlocks.
+func (p *poller) SetTrackerStore(store superwatcher.TrackerStore) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.store = store
+	p.storeLoaded = false
+}
+
+// loadTracker adds blocks saved in p.store to p.tracker. It only loads once, and is a no-op
+// if there is no store or tracker. Blocks are migrated from the policy they were saved with
+// to the current policy, because the snapshot may have been saved by a poller with a different policy.
+func (p *poller) loadTracker(ctx context.Context) error {
+	if p.store == nil || p.tracker == nil || p.storeLoaded {
+		return nil
+	}
+
+	trackedBlocks, err := p.store.LoadTrackedBlocks(ctx)
+	if err != nil {
+		if errors.Is(err, superwatcher.ErrRecordNotFound) {
+			p.debugger.Debug(1, "no tracker snapshot found in store")
+			p.storeLoaded = true
+
+			return nil
+		}
+
+		return err
+	}
+
+	// A snapshot is saved with a single policy, see saveTracker
+	savedPolicy := p.policy
+	for _, trackedBlock := range trackedBlocks {
+		p.tracker.addTrackerBlock(trackedBlock.Block())
+		if trackedBlock.HeadersOnly {
+			p.tracker.markHeadersOnly(trackedBlock.Number)
+		}
+
+		savedPolicy = trackedBlock.Policy
+	}
+
+	p.tracker.migratePolicy(savedPolicy, p.policy)
+	p.storeLoaded = true
+
+	p.debugger.Debug(
+		1, "loaded tracker from store",
+		zap.Int("blocks", len(trackedBlocks)),
+		zap.String("savedPolicy", savedPolicy.String()),
+	)
+	return nil
+}
+
+// saveTracker saves a snapshot of p.tracker to p.store. Failing to save does not fail the poll,
+// since the next successful save will overwrite the stale snapshot anyway.
+func (p *poller) saveTracker(ctx context.Context) {
+	if p.store == nil || p.tracker == nil {
+		return
+	}
+
+	blocks := p.tracker.blocks()
+	trackedBlocks := make([]*superwatcher.TrackedBlock, len(blocks))
+	for i, b := range blocks {
+		trackedBlocks[i] = superwatcher.NewTrackedBlock(b)
+		trackedBlocks[i].Policy = p.policy
+		trackedBlocks[i].HeadersOnly = p.tracker.isHeadersOnly(b.Number)
+	}
+
+	if err := p.store.SaveTrackedBlocks(ctx, trackedBlocks); err != nil {
+		p.debugger.Warn(1, "failed to save tracker to store", zap.Error(err))
+	}
+}
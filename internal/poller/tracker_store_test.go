@@ -0,0 +1,162 @@
+package poller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/pkg/trackerstore"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+// TestTrackerStore checks that a restarted poller reloads its tracker from the store,
+// and reports blocks reorged during the downtime as ReorgedBlocks.
+func TestTrackerStore(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	for _, withStore := range []bool{true, false} {
+		client := newTestSim(t, tc.Param, logs, reorgEvent)
+
+		store := trackerstore.NewFileStore(filepath.Join(t.TempDir(), "tracker.json"))
+		newPoller := func() superwatcher.EmitterPoller {
+			p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+			if withStore {
+				p.SetTrackerStore(store)
+			}
+
+			return p
+		}
+
+		// The 1st poll triggers the reorg event in ReorgSim, which happens while the poller is down
+		if _, err := newPoller().Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+			t.Fatal("unexpected error from 1st poll", err.Error())
+		}
+
+		forkSim(t, client, tc.FromBlock, tc.ToBlock)
+		result, err := newPoller().Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatal("unexpected error from poll after restart", err.Error())
+		}
+
+		if !withStore {
+			if len(result.ReorgedBlocks) != 0 {
+				t.Fatalf("expecting no reorged blocks without store, got %d", len(result.ReorgedBlocks))
+			}
+
+			continue
+		}
+
+		var expected int
+		for n, blockLogs := range logs {
+			if n >= reorgEvent.ReorgBlock && n <= tc.ToBlock && len(blockLogs) != 0 {
+				expected++
+			}
+		}
+
+		if len(result.ReorgedBlocks) != expected {
+			t.Fatalf("expecting %d reorged blocks after restart, got %d", expected, len(result.ReorgedBlocks))
+		}
+
+		for _, b := range result.ReorgedBlocks {
+			if b.Number < reorgEvent.ReorgBlock {
+				t.Fatalf("unexpected reorged block %d before reorg block %d", b.Number, reorgEvent.ReorgBlock)
+			}
+
+			if len(b.Logs) != len(logs[b.Number]) {
+				t.Fatalf("expecting %d logs in reorged block %d, got %d", len(logs[b.Number]), b.Number, len(b.Logs))
+			}
+		}
+	}
+}
+
+// TestTrackerStorePolicy checks that a poller restarted with a different policy
+// migrates the reloaded tracker from the policy it was saved with, and does not produce false reorgs.
+func TestTrackerStorePolicy(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	reorgEvent := tc.Events[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	for _, from := range allPolicies {
+		for _, to := range allPolicies {
+			if from == to {
+				continue
+			}
+
+			client := newTestSim(t, tc.Param, logs, reorgEvent)
+			store := trackerstore.NewFileStore(filepath.Join(t.TempDir(), "tracker.json"))
+			newPoller := func(policy superwatcher.Policy) superwatcher.EmitterPoller {
+				p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, policy)
+				p.SetTrackerStore(store)
+
+				return p
+			}
+
+			if _, err := newPoller(from).Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+				t.Fatalf("[%s -> %s] unexpected error from 1st poll: %s", from, to, err.Error())
+			}
+
+			forkSim(t, client, tc.FromBlock, tc.ToBlock)
+			result, err := newPoller(to).Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+			if err != nil {
+				t.Fatalf("[%s -> %s] unexpected error from poll after restart: %s", from, to, err.Error())
+			}
+
+			reorged := make(map[uint64]bool)
+			for _, b := range result.ReorgedBlocks {
+				reorged[b.Number] = true
+
+				if b.Number < reorgEvent.ReorgBlock {
+					t.Errorf("[%s -> %s] block %d is before reorgBlock %d but was reorged", from, to, b.Number, reorgEvent.ReorgBlock)
+				}
+			}
+
+			for number := reorgEvent.ReorgBlock; number <= tc.ToBlock; number++ {
+				if len(logs[number]) != 0 && !reorged[number] {
+					t.Errorf("[%s -> %s] block %d with logs was not reorged", from, to, number)
+				}
+			}
+		}
+	}
+}
+
+// TestTrackerStoreHeadersOnly checks that blocks tracked with PolicyHeaders, and not yet tracked again
+// after SetPolicy, are still compared by hashes only after a restart.
+func TestTrackerStoreHeadersOnly(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+	client := newTestSim(t, tc.Param, logs)
+
+	store := trackerstore.NewFileStore(filepath.Join(t.TempDir(), "tracker.json"))
+	newPoller := func(policy superwatcher.Policy) superwatcher.EmitterPoller {
+		p := New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, policy)
+		p.SetTrackerStore(store)
+
+		return p
+	}
+
+	p := newPoller(superwatcher.PolicyHeaders)
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock); err != nil {
+		t.Fatal("unexpected error from 1st poll", err.Error())
+	}
+
+	// Only the 1st block is tracked again with PolicyNormal before the snapshot is saved
+	if err := p.SetPolicy(superwatcher.PolicyNormal); err != nil {
+		t.Fatal("unexpected error from SetPolicy", err.Error())
+	}
+	if _, err := p.Poll(context.Background(), tc.FromBlock, tc.FromBlock); err != nil {
+		t.Fatal("unexpected error from 2nd poll", err.Error())
+	}
+
+	result, err := newPoller(superwatcher.PolicyNormal).Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+	if err != nil {
+		t.Fatal("unexpected error from poll after restart", err.Error())
+	}
+
+	if len(result.ReorgedBlocks) != 0 {
+		t.Fatalf("expecting no reorged blocks, got %d", len(result.ReorgedBlocks))
+	}
+}
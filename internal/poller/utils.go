@@ -91,6 +91,51 @@ func collectHeaders(
 	return last, nil
 }
 
+// collectBlocks is like collectHeaders, but it also attaches full block data
+// (header and transactions) to the results already in |m|.
+func collectBlocks(
+	m map[uint64]*mapLogsResult,
+	fromBlock uint64,
+	toBlock uint64,
+	blocks map[uint64]*superwatcher.Block,
+) (
+	uint64,
+	error,
+) {
+	last := fromBlock
+	for n := fromBlock; n <= toBlock; n++ {
+		block, ok := blocks[n]
+		if !ok {
+			continue
+		}
+
+		result, ok := m[n]
+		last = n
+
+		if ok {
+			if rHash, bHash := result.Hash, block.Hash; rHash != bHash {
+				return last, errors.Wrapf(errHashesDiffer, "block %d resultHash differs from blockHash: %s vs %s",
+					n, hashStr(rHash), hashStr(bHash),
+				)
+			}
+
+			result.Header = block.Header
+			result.Transactions = block.Transactions
+		} else {
+			m[n] = &mapLogsResult{
+				Block: superwatcher.Block{
+					Number:       n,
+					Header:       block.Header,
+					Hash:         block.Hash,
+					Transactions: block.Transactions,
+				},
+			}
+		}
+	}
+
+	return last, nil
+}
+
 func hashStr(h common.Hash) string {
 	return gsl.StringerToLowerString(h)
 }
@@ -4,12 +4,16 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
 )
 
 func (e *thinEngine) handleResults(ctx context.Context) error { //nolint:unused
 	for {
 		result := e.emitterClient.WatcherResult()
-		err := e.serviceEngine.HandleFilterResult(result)
+
+		// Only deliver blocks with enough confirmations
+		err := e.serviceEngine.HandleFilterResult(superwatcher.ConfirmedResult(result))
 		if err != nil {
 			return errors.Wrap(errors.WithStack(err), "serviceEngine returned error")
 		}
@@ -2,6 +2,7 @@ package batch
 
 import (
 	"context"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/pkg/errors"
@@ -10,35 +11,159 @@ import (
 )
 
 // Interface is an intermediate type for passing to CallBatch.
+// Unmarshal is called with elements whose rpc.BatchElem.Error is still set after all retries,
+// so implementations should check elem.Error before using elem.Result.
 type Interface interface {
 	Marshal() (rpc.BatchElem, error)
 	Unmarshal(rpc.BatchElem) error
 }
 
-const MethodGetBlockByNumber = "eth_getBlockByNumber"
+const (
+	MethodGetBlockByNumber      = "eth_getBlockByNumber"
+	MethodGetBlockReceipts      = "eth_getBlockReceipts"
+	MethodGetTransactionReceipt = "eth_getTransactionReceipt"
+)
+
+// Options configures CallBatchWithOptions.
+type Options struct {
+	MaxBatchSize int // Max number of elements in each BatchCallContext call, 0 means no limit
+	MaxParallel  int // Max number of concurrent BatchCallContext calls, 0 means 1
+	MaxRetries   int // Max number of retries for elements with rpc.BatchElem.Error
+}
+
+// DefaultOptions is small enough for most RPC providers' batch limits.
+var DefaultOptions = Options{
+	MaxBatchSize: 100,
+	MaxParallel:  4,
+	MaxRetries:   2,
+}
 
 // CallBatch gets []rpc.BatchElem from each batchCall.Marshal() in |batchCalls|.
 // It then used |ctx| and the slice []rpc.BatchElem to call client.BatchCallContext.
 // After the client call, it interates through |calls| calling Unmarshal.
 // This means that batchCalls will have their values updated from Unmarshal after CallBatch returns.
+// All elements are sent in one BatchCallContext call without retries, see CallBatchWithOptions.
 func CallBatch(ctx context.Context, client superwatcher.EthClientRPC, batchCalls []Interface) error {
+	return CallBatchWithOptions(ctx, client, batchCalls, Options{})
+}
+
+// CallBatchWithOptions is like CallBatch, but it splits |batchCalls| into chunks of at most |opts.MaxBatchSize|
+// elements, and calls client.BatchCallContext with up to |opts.MaxParallel| chunks concurrently.
+// Elements that failed (rpc.BatchElem.Error is not nil) are marshaled and sent again, up to |opts.MaxRetries| times.
+// If a BatchCallContext call itself fails, CallBatchWithOptions returns the error without retrying.
+func CallBatchWithOptions(
+	ctx context.Context,
+	client superwatcher.EthClientRPC,
+	batchCalls []Interface,
+	opts Options,
+) error {
 	batchElems := make([]rpc.BatchElem, len(batchCalls))
-	for i, call := range batchCalls {
-		elem, err := call.Marshal()
-		if err != nil {
-			return errors.Wrapf(err, "failed to marshal calls[%d]", i)
+	pending := make([]int, len(batchCalls))
+	for i := range batchCalls {
+		pending[i] = i
+	}
+
+	for retries := 0; len(pending) != 0; retries++ {
+		for _, i := range pending {
+			elem, err := batchCalls[i].Marshal()
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal calls[%d]", i)
+			}
+
+			batchElems[i] = elem
 		}
 
-		batchElems[i] = elem
-	}
+		if err := callChunks(ctx, client, batchElems, pending, opts); err != nil {
+			return err
+		}
+
+		if retries >= opts.MaxRetries {
+			break
+		}
 
-	if err := client.BatchCallContext(ctx, batchElems); err != nil {
-		return errors.Wrap(err, "BatchCallContext failed")
+		var failed []int
+		for _, i := range pending {
+			if batchElems[i].Error != nil {
+				failed = append(failed, i)
+			}
+		}
+
+		pending = failed
 	}
 
 	for i, call := range batchCalls {
 		if err := call.Unmarshal(batchElems[i]); err != nil {
-			return errors.Wrapf(err, "failed to marshal calls[%d]", i)
+			return errors.Wrapf(err, "failed to unmarshal calls[%d]", i)
+		}
+	}
+
+	return nil
+}
+
+// callChunks calls client.BatchCallContext for |batchElems| at indexes |pending|,
+// in chunks of at most |opts.MaxBatchSize| elements. Results are written back to |batchElems|.
+func callChunks(
+	ctx context.Context,
+	client superwatcher.EthClientRPC,
+	batchElems []rpc.BatchElem,
+	pending []int,
+	opts Options,
+) error {
+	size := opts.MaxBatchSize
+	if size <= 0 {
+		size = len(pending)
+	}
+
+	parallel := opts.MaxParallel
+	if parallel <= 0 {
+		parallel = 1
+	}
+
+	var chunks [][]int
+	for start := 0; start < len(pending); start += size {
+		end := start + size
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		chunks = append(chunks, pending[start:end])
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	errs := make([]error, len(chunks))
+
+	for c, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(c int, chunk []int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			elems := make([]rpc.BatchElem, len(chunk))
+			for j, i := range chunk {
+				elems[j] = batchElems[i]
+			}
+
+			if err := client.BatchCallContext(ctx, elems); err != nil {
+				errs[c] = errors.Wrapf(err, "BatchCallContext failed for chunk %d", c)
+				return
+			}
+
+			for j, i := range chunk {
+				batchElems[i] = elems[j]
+			}
+		}(c, chunk)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
 		}
 	}
 
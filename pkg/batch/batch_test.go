@@ -0,0 +1,105 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// mockClient fails each element with an odd argument on its first call,
+// and records the size of each BatchCallContext call.
+type mockClient struct {
+	sync.Mutex
+	sizes  []int
+	called map[int]int
+}
+
+func (c *mockClient) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.sizes = append(c.sizes, len(elems))
+	for i := range elems {
+		arg := elems[i].Args[0].(int)
+		c.called[arg]++
+		if arg%2 == 1 && c.called[arg] == 1 {
+			elems[i].Error = errors.New("mock error")
+			continue
+		}
+
+		*elems[i].Result.(*int) = arg * 10
+	}
+
+	return nil
+}
+
+type mockCall struct {
+	arg    int
+	result int
+	err    error
+}
+
+func (m *mockCall) Marshal() (rpc.BatchElem, error) {
+	return rpc.BatchElem{
+		Method: "mock",
+		Args:   []interface{}{m.arg},
+		Result: new(int),
+	}, nil
+}
+
+func (m *mockCall) Unmarshal(elem rpc.BatchElem) error {
+	m.err = elem.Error
+	m.result = *elem.Result.(*int)
+
+	return nil
+}
+
+func TestCallBatchWithOptions(t *testing.T) {
+	for _, maxRetries := range []int{0, 1} {
+		client := &mockClient{called: make(map[int]int)}
+		calls := make([]Interface, 25)
+		for i := range calls {
+			calls[i] = &mockCall{arg: i}
+		}
+
+		opts := Options{MaxBatchSize: 10, MaxParallel: 2, MaxRetries: maxRetries}
+		if err := CallBatchWithOptions(context.Background(), client, calls, opts); err != nil {
+			t.Fatal("unexpected error", err.Error())
+		}
+
+		for _, size := range client.sizes {
+			if size > opts.MaxBatchSize {
+				t.Fatalf("batch size %d exceeds max batch size %d", size, opts.MaxBatchSize)
+			}
+		}
+
+		for _, call := range calls {
+			m := call.(*mockCall)
+
+			// Only failed elements are retried
+			expectedCalled := 1
+			if m.arg%2 == 1 {
+				expectedCalled += maxRetries
+			}
+			if client.called[m.arg] != expectedCalled {
+				t.Fatalf("expecting %d calls for arg %d, got %d", expectedCalled, m.arg, client.called[m.arg])
+			}
+
+			failed := m.arg%2 == 1 && maxRetries == 0
+			if failed {
+				if m.err == nil {
+					t.Fatalf("expecting error for arg %d", m.arg)
+				}
+
+				continue
+			}
+
+			if m.err != nil || m.result != m.arg*10 {
+				t.Fatalf("unexpected result for arg %d: %d, %v", m.arg, m.result, m.err)
+			}
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package budget
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/batch"
+)
+
+// DefaultCosts are compute units (CU) per call used for methods not in Options.Costs,
+// roughly following common node providers' pricing.
+var DefaultCosts = map[string]uint64{
+	"BlockNumber":                     10,
+	"SafeBlockNumber":                 16,
+	"FinalizedBlockNumber":            16,
+	"FilterLogs":                      75,
+	"HeaderByNumber":                  16,
+	"SubscribeNewHead":                10,
+	batch.MethodGetBlockByNumber:      16,
+	batch.MethodGetBlockReceipts:      500,
+	batch.MethodGetTransactionReceipt: 15,
+}
+
+// Limit rate-limits calls of a method with a token bucket.
+type Limit struct {
+	Rate  float64 // Calls per second, 0 means no limit
+	Burst int     // Max number of calls sent at once, 0 means 1
+}
+
+// Options configures rate limits, costs and budget of the client.
+type Options struct {
+	// Limits rate-limits calls by EthClient method name (e.g. "FilterLogs"),
+	// and batch elements by JSON-RPC method name (e.g. batch.MethodGetBlockByNumber)
+	Limits map[string]Limit
+	// Costs are CU per call by EthClient method name, and per batch element by JSON-RPC method name.
+	// Methods not in Costs use DefaultCosts, and methods in neither cost nothing.
+	Costs map[string]uint64
+	// Budget is the max CU spent per Window, 0 means no budget
+	Budget uint64
+	// Window is the period of Budget, 0 means 1 minute
+	Window time.Duration
+	// Degrade makes TakeCost report superwatcher.Cost.OverBudget after Budget is exceeded,
+	// so that EmitterPoller using the client degrades itself
+	Degrade bool
+	// OnExceeded is called the first time Budget is exceeded in each Window, may be nil
+	OnExceeded func(used, budget uint64)
+}
+
+// Client is superwatcher.EthClient that accounts for its RPC costs.
+type Client interface {
+	superwatcher.EthClient
+	superwatcher.CostReporter
+}
+
+// client implements Client by rate-limiting and counting calls to the wrapped EthClient.
+// Calls are counted before they are sent, so failed calls are counted too, like node providers bill them.
+type client struct {
+	sync.Mutex
+
+	client superwatcher.EthClient
+	opts   Options
+	limits map[string]*bucket
+
+	cost superwatcher.Cost // Cost since the last TakeCost
+
+	windowStart time.Time
+	windowCU    uint64 // CU spent since windowStart
+	exceeded    bool   // Whether Budget was exceeded since windowStart
+}
+
+// New returns Client that rate-limits and counts calls to |ethClient| as configured by |opts|.
+func New(ethClient superwatcher.EthClient, opts Options) Client {
+	if opts.Window == 0 {
+		opts.Window = time.Minute
+	}
+
+	limits := make(map[string]*bucket)
+	for method, limit := range opts.Limits {
+		if limit.Rate > 0 {
+			limits[method] = newBucket(limit)
+		}
+	}
+
+	return &client{
+		client: ethClient,
+		opts:   opts,
+		limits: limits,
+		cost:   superwatcher.Cost{Calls: make(map[string]uint64)},
+	}
+}
+
+// TakeCost returns the cost since the last call to TakeCost, and resets it.
+func (c *client) TakeCost() superwatcher.Cost {
+	c.Lock()
+	defer c.Unlock()
+
+	cost := c.cost
+	c.cost = superwatcher.Cost{Calls: make(map[string]uint64)}
+
+	return cost
+}
+
+// costOf returns CU per call of |method|
+func (c *client) costOf(method string) uint64 {
+	if cu, ok := c.opts.Costs[method]; ok {
+		return cu
+	}
+
+	return DefaultCosts[method]
+}
+
+// spend waits for rate limits of |methods|, and then counts their calls and CU.
+func (c *client) spend(ctx context.Context, methods ...string) error {
+	for _, method := range methods {
+		if limit, ok := c.limits[method]; ok {
+			if err := limit.wait(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	var cu uint64
+	for _, method := range methods {
+		cu += c.costOf(method)
+	}
+
+	c.Lock()
+
+	for _, method := range methods {
+		c.cost.Calls[method]++
+	}
+	c.cost.ComputeUnits += cu
+
+	now := time.Now()
+	if now.Sub(c.windowStart) >= c.opts.Window {
+		c.windowStart = now
+		c.windowCU = 0
+		c.exceeded = false
+	}
+
+	c.windowCU += cu
+	exceeded := c.opts.Budget != 0 && c.windowCU > c.opts.Budget && !c.exceeded
+	if exceeded {
+		c.exceeded = true
+		c.cost.OverBudget = c.opts.Degrade
+	}
+
+	used := c.windowCU
+	c.Unlock()
+
+	if exceeded && c.opts.OnExceeded != nil {
+		c.opts.OnExceeded(used, c.opts.Budget)
+	}
+
+	return nil
+}
+
+func (c *client) BlockNumber(ctx context.Context) (uint64, error) {
+	if err := c.spend(ctx, "BlockNumber"); err != nil {
+		return 0, err
+	}
+
+	return c.client.BlockNumber(ctx)
+}
+
+func (c *client) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	if err := c.spend(ctx, "SafeBlockNumber"); err != nil {
+		return 0, err
+	}
+
+	return c.client.SafeBlockNumber(ctx)
+}
+
+func (c *client) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	if err := c.spend(ctx, "FinalizedBlockNumber"); err != nil {
+		return 0, err
+	}
+
+	return c.client.FinalizedBlockNumber(ctx)
+}
+
+func (c *client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if err := c.spend(ctx, "FilterLogs"); err != nil {
+		return nil, err
+	}
+
+	return c.client.FilterLogs(ctx, q)
+}
+
+func (c *client) HeaderByNumber(ctx context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
+	if err := c.spend(ctx, "HeaderByNumber"); err != nil {
+		return nil, err
+	}
+
+	return c.client.HeaderByNumber(ctx, number)
+}
+
+// BatchCallContext counts each of |elems| as a call of its JSON-RPC method.
+func (c *client) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	methods := make([]string, len(elems))
+	for i := range elems {
+		methods[i] = elems[i].Method
+	}
+
+	if err := c.spend(ctx, methods...); err != nil {
+		return err
+	}
+
+	return c.client.BatchCallContext(ctx, elems)
+}
+
+func (c *client) SubscribeNewHead(ctx context.Context, ch chan<- superwatcher.BlockHeader) (ethereum.Subscription, error) {
+	if err := c.spend(ctx, "SubscribeNewHead"); err != nil {
+		return nil, err
+	}
+
+	return c.client.SubscribeNewHead(ctx, ch)
+}
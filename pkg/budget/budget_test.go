@@ -0,0 +1,111 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/batch"
+)
+
+type fakeClient struct {
+	superwatcher.EthClient
+}
+
+func (fakeClient) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (fakeClient) BatchCallContext(context.Context, []rpc.BatchElem) error {
+	return nil
+}
+
+func TestCost(t *testing.T) {
+	ctx := context.Background()
+
+	var exceeded int
+	c := New(fakeClient{}, Options{
+		Costs:      map[string]uint64{"FilterLogs": 100},
+		Budget:     250,
+		Degrade:    true,
+		OnExceeded: func(uint64, uint64) { exceeded++ },
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.FilterLogs(ctx, ethereum.FilterQuery{}); err != nil {
+			t.Fatal("unexpected FilterLogs error", err.Error())
+		}
+	}
+
+	elems := []rpc.BatchElem{
+		{Method: batch.MethodGetBlockByNumber},
+		{Method: batch.MethodGetBlockByNumber},
+		{Method: batch.MethodGetBlockReceipts},
+	}
+	if err := c.BatchCallContext(ctx, elems); err != nil {
+		t.Fatal("unexpected BatchCallContext error", err.Error())
+	}
+
+	cost := c.TakeCost()
+	expectedCU := 2*100 + 2*DefaultCosts[batch.MethodGetBlockByNumber] + DefaultCosts[batch.MethodGetBlockReceipts]
+	if cost.ComputeUnits != expectedCU {
+		t.Fatalf("expecting %d CU, got %d", expectedCU, cost.ComputeUnits)
+	}
+	if cost.Calls["FilterLogs"] != 2 || cost.Calls[batch.MethodGetBlockByNumber] != 2 || cost.Calls[batch.MethodGetBlockReceipts] != 1 {
+		t.Fatalf("unexpected calls %v", cost.Calls)
+	}
+	if !cost.OverBudget || exceeded != 1 {
+		t.Fatalf("expecting budget exceeded once, got OverBudget %v and %d OnExceeded calls", cost.OverBudget, exceeded)
+	}
+
+	// TakeCost resets the cost, and exceeding the budget again in the same window is not reported again
+	if _, err := c.FilterLogs(ctx, ethereum.FilterQuery{}); err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	cost = c.TakeCost()
+	if cost.ComputeUnits != 100 || cost.OverBudget || exceeded != 1 {
+		t.Fatalf("unexpected cost after TakeCost: %+v, %d OnExceeded calls", cost, exceeded)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	c := New(fakeClient{}, Options{
+		Limits: map[string]Limit{"FilterLogs": {Rate: 20, Burst: 2}},
+	})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := c.FilterLogs(ctx, ethereum.FilterQuery{}); err != nil {
+			t.Fatal("unexpected FilterLogs error", err.Error())
+		}
+	}
+
+	// 2 calls are sent at once, the other 2 wait for 1/20 second each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expecting rate-limited calls to take at least 100ms, took %s", elapsed)
+	}
+
+	// Batch calls are not limited by FilterLogs limit
+	start = time.Now()
+	if err := c.BatchCallContext(ctx, []rpc.BatchElem{{Method: batch.MethodGetBlockByNumber}}); err != nil {
+		t.Fatal("unexpected BatchCallContext error", err.Error())
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("unexpected wait for batch call: %s", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for i := 0; i < 2; i++ {
+		if _, err := c.FilterLogs(canceled, ethereum.FilterQuery{}); err == nil && i == 1 {
+			t.Fatal("expecting error from rate-limited call with canceled context")
+		}
+	}
+}
@@ -40,11 +40,14 @@ func NewEmitterWithPoller(
 	pollResultChan chan<- *superwatcher.PollerResult,
 	errChan chan<- error,
 ) superwatcher.Emitter {
+	poller := NewPoller(addresses, topics, conf.DoReorg, conf.DoHeader, conf.FilterRange, client, conf.LogLevel, conf.Policy)
+	configurePoller(poller, &componentConfig{config: conf})
+
 	return emitter.New(
 		conf,
 		client,
 		stateDataGateway,
-		NewPoller(addresses, topics, conf.DoReorg, conf.DoHeader, conf.FilterRange, client, conf.LogLevel, conf.Policy),
+		poller,
 		syncChan,
 		pollResultChan,
 		errChan,
@@ -68,6 +71,8 @@ func NewEmitterOptions(options ...Option) superwatcher.Emitter {
 		c.policy,
 	)
 
+	configurePoller(poller, &c)
+
 	return emitter.New(
 		c.config,
 		c.ethClient,
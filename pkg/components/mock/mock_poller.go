@@ -86,15 +86,69 @@ func (p *mockPoller) PollNg(context.Context, uint64, uint64) (*superwatcher.Poll
 	return nil, nil
 }
 
-func (p *mockPoller) SetDoReorg(bool)                     {}
-func (p *mockPoller) DoReorg() bool                       { return true }
-func (p *mockPoller) SetDoHeader(bool)                    {}
-func (p *mockPoller) DoHeader() bool                      { return true }
-func (p *mockPoller) Addresses() []common.Address         { return nil }
-func (p *mockPoller) Topics() [][]common.Hash             { return nil }
-func (p *mockPoller) AddAddresses(...common.Address)      {}
-func (p *mockPoller) AddTopics(...[]common.Hash)          {}
-func (p *mockPoller) SetAddresses([]common.Address)       {}
-func (p *mockPoller) SetTopics([][]common.Hash)           {}
-func (p *mockPoller) SetPolicy(superwatcher.Policy) error { return nil }
-func (p *mockPoller) Policy() superwatcher.Policy         { return superwatcher.PolicyNormal }
+func (p *mockPoller) Backfill(
+	ctx context.Context,
+	fromBlock, toBlock uint64,
+) (
+	*superwatcher.PollerResult,
+	error,
+) {
+	result := &superwatcher.PollerResult{FromBlock: fromBlock, ToBlock: toBlock, LastGoodBlock: toBlock}
+	for n := fromBlock; n <= toBlock; n++ {
+		result.GoodBlocks = append(result.GoodBlocks, &superwatcher.Block{
+			Number: n,
+			Hash:   common.BigToHash(big.NewInt(int64(n))),
+		})
+	}
+
+	return result, nil
+}
+
+func (p *mockPoller) Rewind(
+	ctx context.Context,
+	fromBlock, toBlock uint64,
+) (
+	*superwatcher.PollerResult,
+	error,
+) {
+	result, err := p.Backfill(ctx, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ReorgedBlocks, result.GoodBlocks = result.GoodBlocks, nil
+	result.LastGoodBlock = fromBlock - 1
+	result.Rewind = true
+
+	return result, nil
+}
+
+func (p *mockPoller) SetDoReorg(bool)                                 {}
+func (p *mockPoller) DoReorg() bool                                   { return true }
+func (p *mockPoller) SetDoHeader(bool)                                {}
+func (p *mockPoller) DoHeader() bool                                  { return true }
+func (p *mockPoller) SetDoParentHash(bool)                            {}
+func (p *mockPoller) DoParentHash() bool                              { return false }
+func (p *mockPoller) SetDoFinality(bool)                              {}
+func (p *mockPoller) DoFinality() bool                                { return false }
+func (p *mockPoller) SetDoVerifyBlockHash(bool)                       {}
+func (p *mockPoller) DoVerifyBlockHash() bool                         { return false }
+func (p *mockPoller) SetDoReceipts(bool)                              {}
+func (p *mockPoller) DoReceipts() bool                                { return false }
+func (p *mockPoller) Addresses() []common.Address                     { return nil }
+func (p *mockPoller) Topics() [][]common.Hash                         { return nil }
+func (p *mockPoller) AddAddresses(...common.Address)                  {}
+func (p *mockPoller) AddTopics(...[]common.Hash)                      {}
+func (p *mockPoller) SetAddresses([]common.Address)                   {}
+func (p *mockPoller) SetTopics([][]common.Hash)                       {}
+func (p *mockPoller) AddSubscription(superwatcher.Subscription) error { return nil }
+func (p *mockPoller) RemoveSubscription(string) error                 { return nil }
+func (p *mockPoller) Subscriptions() []superwatcher.Subscription      { return nil }
+func (p *mockPoller) SetPolicy(superwatcher.Policy) error             { return nil }
+func (p *mockPoller) FilterRangeLimit() uint64                        { return 0 }
+func (p *mockPoller) SetTrackerStore(superwatcher.TrackerStore)       {}
+func (p *mockPoller) SetRetryPolicy(*superwatcher.RetryPolicy)        {}
+func (p *mockPoller) SetDiscovery(superwatcher.DiscoveryFunc)         {}
+func (p *mockPoller) AddLogPredicates(...superwatcher.LogPredicate)   {}
+func (p *mockPoller) SetAddressChunkSize(uint64)                      {}
+func (p *mockPoller) Policy() superwatcher.Policy                     { return superwatcher.PolicyNormal }
@@ -19,7 +19,14 @@ type componentConfig struct {
 	topics              [][]common.Hash
 	doReorg             bool
 	doHeader            bool
+	doParentHash        bool
+	doReceipts          bool
+	doFinality          bool
+	doVerifyBlockHash   bool
 	filterRange         uint64
+	trackerStore        superwatcher.TrackerStore
+	discovery           superwatcher.DiscoveryFunc
+	predicates          []superwatcher.LogPredicate
 	policy              superwatcher.Policy
 	logLevel            uint8 // redundant in conf, but users may want to set this separately
 	syncChan            chan struct{}
@@ -115,6 +122,53 @@ func WithDoHeader(doHeader bool) Option {
 	}
 }
 
+func WithDoParentHash(doParentHash bool) Option {
+	return func(c *componentConfig) {
+		c.doParentHash = doParentHash
+	}
+}
+
+func WithDoReceipts(doReceipts bool) Option {
+	return func(c *componentConfig) {
+		c.doReceipts = doReceipts
+	}
+}
+
+func WithDoFinality(doFinality bool) Option {
+	return func(c *componentConfig) {
+		c.doFinality = doFinality
+	}
+}
+
+func WithDoVerifyBlockHash(doVerifyBlockHash bool) Option {
+	return func(c *componentConfig) {
+		c.doVerifyBlockHash = doVerifyBlockHash
+	}
+}
+
+// WithDiscovery sets the hook used by the poller to discover new addresses and topics from polled logs.
+// Discovery cannot be used with Config.BackfillWorkers, see superwatcher.ErrBadBackfill.
+func WithDiscovery(discovery superwatcher.DiscoveryFunc) Option {
+	return func(c *componentConfig) {
+		c.discovery = discovery
+	}
+}
+
+// WithLogPredicates adds predicates run by the poller on every polled log. Only logs matching all predicates are emitted.
+func WithLogPredicates(predicates ...superwatcher.LogPredicate) Option {
+	return func(c *componentConfig) {
+		c.predicates = append(c.predicates, predicates...)
+	}
+}
+
+// WithTrackerStore sets the store used by the poller to persist its tracked blocks.
+// If not set, the default file-backed store is used if Config.TrackerFile is set.
+func WithTrackerStore(store superwatcher.TrackerStore) Option {
+	return func(c *componentConfig) {
+		c.trackerStore = store
+	}
+}
+
 func WithPolicy(level superwatcher.Policy) Option {
 	return func(c *componentConfig) {
 		c.policy = level
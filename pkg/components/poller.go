@@ -6,6 +6,7 @@ import (
 
 	"github.com/soyart/superwatcher"
 	"github.com/soyart/superwatcher/internal/poller"
+	"github.com/soyart/superwatcher/pkg/trackerstore"
 )
 
 func NewPoller(
@@ -36,7 +37,7 @@ func NewPollerOptions(options ...Option) superwatcher.EmitterPoller {
 		opt(&c)
 	}
 
-	return poller.New(
+	poller := poller.New(
 		c.addresses,
 		c.topics,
 		c.doReorg,
@@ -46,4 +47,52 @@ func NewPollerOptions(options ...Option) superwatcher.EmitterPoller {
 		gsl.Max(c.logLevel, c.config.LogLevel),
 		gsl.Max(c.policy, c.config.Policy),
 	)
+
+	configurePoller(poller, &c)
+
+	return poller
+}
+
+// configurePoller configures |p| with settings from |c| that are not parameters of poller.New.
+// Checks are enabled if they are enabled with either options or c.config.
+func configurePoller(p superwatcher.EmitterPoller, c *componentConfig) {
+	conf := c.config
+	if conf == nil {
+		conf = new(superwatcher.Config)
+	}
+
+	if c.doParentHash || conf.DoParentHash {
+		p.SetDoParentHash(true)
+	}
+	if c.doReceipts || conf.DoReceipts {
+		p.SetDoReceipts(true)
+	}
+	if c.doFinality || conf.DoFinality {
+		p.SetDoFinality(true)
+	}
+	if c.doVerifyBlockHash || conf.DoVerifyBlockHash {
+		p.SetDoVerifyBlockHash(true)
+	}
+
+	if conf.Retry != nil {
+		p.SetRetryPolicy(conf.Retry)
+	}
+	if conf.AddressChunkSize != 0 {
+		p.SetAddressChunkSize(conf.AddressChunkSize)
+	}
+	if c.discovery != nil {
+		p.SetDiscovery(c.discovery)
+	}
+	if len(c.predicates) != 0 {
+		p.AddLogPredicates(c.predicates...)
+	}
+
+	// Use the default file-backed store if no store was given with options
+	store := c.trackerStore
+	if store == nil && conf.TrackerFile != "" {
+		store = trackerstore.NewFileStore(conf.TrackerFile)
+	}
+	if store != nil {
+		p.SetTrackerStore(store)
+	}
 }
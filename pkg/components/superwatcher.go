@@ -36,6 +36,8 @@ func NewSuperWatcherOptions(options ...Option) superwatcher.SuperWatcher {
 		gsl.Max(conf.policy, conf.config.Policy),
 	)
 
+	configurePoller(poller, &conf)
+
 	emitter := NewEmitter(
 		conf.config,
 		conf.ethClient,
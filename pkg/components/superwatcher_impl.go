@@ -41,6 +41,22 @@ func (spw *superWatcher) Shutdown() {
 	spw.emitter.Shutdown()
 }
 
+func (spw *superWatcher) Pause() {
+	spw.emitter.Pause()
+}
+
+func (spw *superWatcher) Resume() {
+	spw.emitter.Resume()
+}
+
+func (spw *superWatcher) SeekTo(block uint64) error {
+	return spw.emitter.SeekTo(block)
+}
+
+func (spw *superWatcher) Rewind(n uint64) {
+	spw.emitter.Rewind(n)
+}
+
 func (spw *superWatcher) SetDoReorg(doReorg bool) {
 	spw.emitter.Poller().SetDoReorg(doReorg)
 }
@@ -57,6 +73,50 @@ func (spw *superWatcher) DoHeader() bool {
 	return spw.emitter.Poller().DoHeader()
 }
 
+func (spw *superWatcher) SetDoParentHash(doParentHash bool) {
+	spw.emitter.Poller().SetDoParentHash(doParentHash)
+}
+
+func (spw *superWatcher) DoParentHash() bool {
+	return spw.emitter.Poller().DoParentHash()
+}
+
+func (spw *superWatcher) SetDoFinality(doFinality bool) {
+	spw.emitter.Poller().SetDoFinality(doFinality)
+}
+
+func (spw *superWatcher) DoFinality() bool {
+	return spw.emitter.Poller().DoFinality()
+}
+
+func (spw *superWatcher) SetDoVerifyBlockHash(doVerifyBlockHash bool) {
+	spw.emitter.Poller().SetDoVerifyBlockHash(doVerifyBlockHash)
+}
+
+func (spw *superWatcher) DoVerifyBlockHash() bool {
+	return spw.emitter.Poller().DoVerifyBlockHash()
+}
+
+func (spw *superWatcher) AddSubscription(sub superwatcher.Subscription) error {
+	return spw.emitter.Poller().AddSubscription(sub)
+}
+
+func (spw *superWatcher) RemoveSubscription(id string) error {
+	return spw.emitter.Poller().RemoveSubscription(id)
+}
+
+func (spw *superWatcher) Subscriptions() []superwatcher.Subscription {
+	return spw.emitter.Poller().Subscriptions()
+}
+
+func (spw *superWatcher) SetDoReceipts(doReceipts bool) {
+	spw.emitter.Poller().SetDoReceipts(doReceipts)
+}
+
+func (spw *superWatcher) DoReceipts() bool {
+	return spw.emitter.Poller().DoReceipts()
+}
+
 func (spw *superWatcher) Addresses() []common.Address {
 	return spw.emitter.Poller().Addresses()
 }
@@ -80,3 +140,11 @@ func (spw *superWatcher) SetAddresses(addresses []common.Address) {
 func (spw *superWatcher) SetTopics(topics [][]common.Hash) {
 	spw.emitter.Poller().SetTopics(topics)
 }
+
+func (spw *superWatcher) Policy() superwatcher.Policy {
+	return spw.emitter.Poller().Policy()
+}
+
+func (spw *superWatcher) SetPolicy(policy superwatcher.Policy) error {
+	return spw.emitter.Poller().SetPolicy(policy)
+}
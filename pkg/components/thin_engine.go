@@ -32,6 +32,7 @@ func NewThinEngineWithEmitter(
 	serviceEngine superwatcher.ThinServiceEngine,
 ) (superwatcher.Emitter, superwatcher.Engine) {
 	poller := NewPoller(addresses, topics, conf.DoReorg, conf.DoHeader, conf.FilterRange, client, conf.LogLevel, policy)
+	configurePoller(poller, &componentConfig{config: conf})
 
 	syncChan := make(chan struct{})
 	resultChan := make(chan *superwatcher.PollerResult)
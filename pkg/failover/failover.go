@@ -0,0 +1,459 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/batch"
+	"github.com/soyart/superwatcher/pkg/logger/debugger"
+)
+
+var ErrNoNode = errors.New("no node available") // All nodes failed the call
+
+// Node is an EthClient with its name (e.g. URL) and priority. Nodes with lower Priority values are preferred.
+type Node struct {
+	Name     string
+	Priority int
+	Client   superwatcher.EthClient
+}
+
+// Options configures health checks of the failover client.
+type Options struct {
+	// MaxHeadLag is the number of blocks a node's head may be behind the highest head before it's unhealthy, 0 means 5
+	MaxHeadLag uint64
+	// MaxHeadAge is how long a node's head may stay unchanged before it's unhealthy, 0 means no limit
+	MaxHeadAge time.Duration
+	// MaxErrorRate is the error rate (0-1, averaged over recent calls) above which a node is unhealthy, 0 means 0.5
+	MaxErrorRate float64
+	// CheckInterval is the interval between background health checks of all nodes, 0 means 15 seconds.
+	// If negative, there's no background checks, and node health only comes from calls sent to the node,
+	// so unhealthy nodes can only recover once all healthy nodes fail.
+	CheckInterval time.Duration
+
+	LogLevel uint8
+}
+
+// errorRateWeight is the weight of the latest call in a node's error rate
+const errorRateWeight = 0.2
+
+// nodeState is the health of a node
+type nodeState struct {
+	Node
+
+	head       uint64    // Latest head seen from the node
+	headAt     time.Time // When head last changed
+	errorRate  float64   // Exponential moving average of failed calls
+	lastFailed bool      // Whether the latest health check failed
+}
+
+// client implements superwatcher.EthClient by sending each call to the preferred healthy node,
+// and failing over to the next nodes if the call fails. Block numbers returned by client never go backwards,
+// even if it fails over to a node that is behind. Calls for blocks (e.g. FilterLogs) are never sent to nodes
+// whose heads are behind the blocks, since these nodes would return empty results instead of errors.
+type client struct {
+	sync.Mutex
+
+	nodes   []*nodeState // Sorted by priority
+	opts    Options
+	current int // Index of the node used for the latest call
+
+	// Highest block numbers returned, so that they never go backwards after switching nodes
+	head, safe, finalized uint64
+
+	debugger *debugger.Debugger
+}
+
+// New returns superwatcher.EthClient that fails over between |nodes|. If opts.CheckInterval is set,
+// health checks run in the background until |ctx| is done.
+func New(ctx context.Context, nodes []Node, opts Options) (superwatcher.EthClient, error) {
+	if len(nodes) == 0 {
+		return nil, errors.New("no nodes for failover")
+	}
+
+	if opts.MaxHeadLag == 0 {
+		opts.MaxHeadLag = 5
+	}
+	if opts.MaxErrorRate == 0 {
+		opts.MaxErrorRate = 0.5
+	}
+	if opts.CheckInterval == 0 {
+		opts.CheckInterval = 15 * time.Second
+	}
+
+	states := make([]*nodeState, len(nodes))
+	for i, node := range nodes {
+		states[i] = &nodeState{Node: node}
+	}
+
+	sort.SliceStable(states, func(i, j int) bool { return states[i].Priority < states[j].Priority })
+
+	c := &client{
+		nodes:    states,
+		opts:     opts,
+		debugger: debugger.NewDebugger("failover", opts.LogLevel),
+	}
+
+	if opts.CheckInterval > 0 {
+		go c.loopCheck(ctx)
+	}
+
+	return c, nil
+}
+
+// Dial dials all |endpoints| and returns a failover client for them.
+// Endpoints that cannot be dialed are skipped, and Dial only returns error if no endpoint can be dialed.
+func Dial(ctx context.Context, endpoints []superwatcher.NodeEndpoint, opts Options) (superwatcher.EthClient, error) {
+	var nodes []Node
+	var dialErrs []string
+	for _, endpoint := range endpoints {
+		ethClient, err := superwatcher.NewEthClient(ctx, endpoint.URL)
+		if err != nil {
+			dialErrs = append(dialErrs, err.Error())
+			continue
+		}
+
+		nodes = append(nodes, Node{Name: endpoint.URL, Priority: endpoint.Priority, Client: ethClient})
+	}
+
+	if len(nodes) == 0 {
+		return nil, errors.Wrapf(ErrNoNode, "failed to dial all %d endpoints: %v", len(endpoints), dialErrs)
+	}
+
+	if len(dialErrs) != 0 {
+		debugger.NewDebugger("failover", opts.LogLevel).Warn(1, "failed to dial some endpoints", zap.Strings("errors", dialErrs))
+	}
+
+	return New(ctx, nodes, opts)
+}
+
+// check gets heads from all nodes, and updates their health.
+func (c *client) check(ctx context.Context) {
+	heads := make([]uint64, len(c.nodes))
+	errs := make([]error, len(c.nodes))
+
+	var wg sync.WaitGroup
+	for i, node := range c.nodes {
+		wg.Add(1)
+		go func(i int, node *nodeState) {
+			defer wg.Done()
+			heads[i], errs[i] = node.Client.BlockNumber(ctx)
+		}(i, node)
+	}
+
+	wg.Wait()
+
+	c.Lock()
+	defer c.Unlock()
+
+	for i, node := range c.nodes {
+		c.record(node, errs[i])
+		node.lastFailed = errs[i] != nil
+		if errs[i] == nil {
+			c.updateHead(node, heads[i])
+		}
+	}
+}
+
+func (c *client) loopCheck(ctx context.Context) {
+	ticker := time.NewTicker(c.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.check(ctx)
+		}
+	}
+}
+
+// healthy returns whether |node| is fresh and reliable enough. Must be called with c locked.
+func (c *client) healthy(node *nodeState) bool {
+	if node.lastFailed || node.errorRate > c.opts.MaxErrorRate {
+		return false
+	}
+
+	best := c.head
+	for _, n := range c.nodes {
+		if n.head > best {
+			best = n.head
+		}
+	}
+
+	if node.head+c.opts.MaxHeadLag < best {
+		return false
+	}
+
+	if c.opts.MaxHeadAge != 0 && !node.headAt.IsZero() && time.Since(node.headAt) > c.opts.MaxHeadAge {
+		return false
+	}
+
+	return true
+}
+
+// order returns nodes to try for the next call: healthy nodes by priority, and then unhealthy ones
+func (c *client) order() []*nodeState {
+	c.Lock()
+	defer c.Unlock()
+
+	var healthy, unhealthy []*nodeState
+	for _, node := range c.nodes {
+		if c.healthy(node) {
+			healthy = append(healthy, node)
+			continue
+		}
+
+		unhealthy = append(unhealthy, node)
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// record updates the error rate of |node| with the result of a call. Must be called with c locked.
+func (c *client) record(node *nodeState, err error) {
+	failed := 0.0
+	if err != nil {
+		failed = 1
+	}
+
+	node.errorRate = node.errorRate*(1-errorRateWeight) + failed*errorRateWeight
+}
+
+// updateHead saves the latest head of |node|. Must be called with c locked.
+func (c *client) updateHead(node *nodeState, head uint64) {
+	if head != node.head {
+		node.head = head
+		node.headAt = time.Now()
+	}
+}
+
+// call calls |f| on nodes in order until a call succeeds. Errors from canceled contexts do not fail over.
+// Nodes whose heads are behind |minHead| are skipped, so that calls for blocks up to |minHead|
+// only go to nodes that have the blocks. 0 means any node can serve the call.
+func call[T any](
+	c *client,
+	ctx context.Context,
+	method string,
+	minHead uint64,
+	f func(superwatcher.EthClient) (T, error),
+) (
+	T,
+	*nodeState,
+	error,
+) {
+	var zero T
+	var errs []string
+	for _, node := range c.order() {
+		if !c.hasHead(ctx, node, minHead) {
+			c.debugger.Debug(1, "node is behind, failing over", zap.String("node", node.Name), zap.String("method", method), zap.Uint64("minHead", minHead))
+			errs = append(errs, fmt.Sprintf("%s: behind block %d", node.Name, minHead))
+			continue
+		}
+
+		result, err := f(node.Client)
+
+		c.Lock()
+		c.record(node, err)
+		if err == nil {
+			c.switchTo(node)
+		}
+		c.Unlock()
+
+		if err == nil {
+			return result, node, nil
+		}
+
+		if ctx.Err() != nil {
+			return zero, node, err
+		}
+
+		c.debugger.Debug(1, "node call failed, failing over", zap.String("node", node.Name), zap.String("method", method), zap.Error(err))
+		errs = append(errs, node.Name+": "+err.Error())
+	}
+
+	return zero, nil, errors.Wrapf(ErrNoNode, "%s failed on all nodes: %v", method, errs)
+}
+
+// hasHead returns whether |node| has block |minHead|. If the last known head of |node| is behind |minHead|,
+// the head is refreshed first, since the node may have caught up since it was last seen.
+func (c *client) hasHead(ctx context.Context, node *nodeState, minHead uint64) bool {
+	c.Lock()
+	head := node.head
+	c.Unlock()
+
+	if head >= minHead {
+		return true
+	}
+
+	head, err := node.Client.BlockNumber(ctx)
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.record(node, err)
+	if err != nil {
+		return false
+	}
+
+	c.updateHead(node, head)
+	return head >= minHead
+}
+
+// currentHead returns the highest head returned by BlockNumber
+func (c *client) currentHead() uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.head
+}
+
+// queryHead returns the newest block queried by |q|, or 0 if |q| is a block hash query.
+// Queries up to the latest block need the highest head returned by BlockNumber.
+func (c *client) queryHead(q ethereum.FilterQuery) uint64 {
+	if q.BlockHash != nil {
+		return 0
+	}
+
+	if q.ToBlock == nil || q.ToBlock.Sign() < 0 {
+		return c.currentHead()
+	}
+
+	return q.ToBlock.Uint64()
+}
+
+// batchHead returns the newest block number queried by `eth_getBlockByNumber` or `eth_getBlockReceipts` in |elems|
+func batchHead(elems []rpc.BatchElem) uint64 {
+	var head uint64
+	for _, elem := range elems {
+		if elem.Method != batch.MethodGetBlockByNumber && elem.Method != batch.MethodGetBlockReceipts || len(elem.Args) == 0 {
+			continue
+		}
+
+		hex, ok := elem.Args[0].(string)
+		if !ok {
+			continue
+		}
+
+		if number, err := hexutil.DecodeUint64(hex); err == nil && number > head {
+			head = number
+		}
+	}
+
+	return head
+}
+
+// switchTo logs when the client switches to |node|. Must be called with c locked.
+func (c *client) switchTo(node *nodeState) {
+	for i, n := range c.nodes {
+		if n != node || i == c.current {
+			continue
+		}
+
+		c.debugger.Warn(
+			1, "switched node",
+			zap.String("from", c.nodes[c.current].Name),
+			zap.String("to", node.Name),
+		)
+
+		c.current = i
+	}
+}
+
+// monotonic returns the highest of |n| and |*highest|, and saves it to |highest|
+func (c *client) monotonic(highest *uint64, n uint64) uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	if n > *highest {
+		*highest = n
+	}
+
+	return *highest
+}
+
+func (c *client) BlockNumber(ctx context.Context) (uint64, error) {
+	head, node, err := call(c, ctx, "BlockNumber", 0, func(client superwatcher.EthClient) (uint64, error) {
+		return client.BlockNumber(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.Lock()
+	c.updateHead(node, head)
+	c.Unlock()
+
+	return c.monotonic(&c.head, head), nil
+}
+
+func (c *client) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	safe, _, err := call(c, ctx, "SafeBlockNumber", 0, func(client superwatcher.EthClient) (uint64, error) {
+		return client.SafeBlockNumber(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return c.monotonic(&c.safe, safe), nil
+}
+
+func (c *client) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	finalized, _, err := call(c, ctx, "FinalizedBlockNumber", 0, func(client superwatcher.EthClient) (uint64, error) {
+		return client.FinalizedBlockNumber(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return c.monotonic(&c.finalized, finalized), nil
+}
+
+func (c *client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	logs, _, err := call(c, ctx, "FilterLogs", c.queryHead(q), func(client superwatcher.EthClient) ([]types.Log, error) {
+		return client.FilterLogs(ctx, q)
+	})
+
+	return logs, err
+}
+
+func (c *client) HeaderByNumber(ctx context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
+	var minHead uint64
+	if number != nil && number.Sign() > 0 {
+		minHead = number.Uint64()
+	}
+
+	header, _, err := call(c, ctx, "HeaderByNumber", minHead, func(client superwatcher.EthClient) (superwatcher.BlockHeader, error) {
+		return client.HeaderByNumber(ctx, number)
+	})
+
+	return header, err
+}
+
+func (c *client) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	_, _, err := call(c, ctx, "BatchCallContext", batchHead(elems), func(client superwatcher.EthClient) (struct{}, error) {
+		return struct{}{}, client.BatchCallContext(ctx, elems)
+	})
+
+	return err
+}
+
+func (c *client) SubscribeNewHead(ctx context.Context, ch chan<- superwatcher.BlockHeader) (ethereum.Subscription, error) {
+	sub, _, err := call(c, ctx, "SubscribeNewHead", 0, func(client superwatcher.EthClient) (ethereum.Subscription, error) {
+		return client.SubscribeNewHead(ctx, ch)
+	})
+
+	return sub, err
+}
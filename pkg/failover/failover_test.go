@@ -0,0 +1,164 @@
+package failover
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/pkg/batch"
+)
+
+// fakeNode returns |head| from BlockNumber, and fails all calls if |down|
+type fakeNode struct {
+	superwatcher.EthClient
+
+	name  string
+	head  uint64
+	down  bool
+	calls int
+}
+
+func (n *fakeNode) BlockNumber(context.Context) (uint64, error) {
+	n.calls++
+	if n.down {
+		return 0, errors.New(n.name + " is down")
+	}
+
+	return n.head, nil
+}
+
+// FilterLogs returns 1 log for each block in range, but like real nodes, only up to n.head
+func (n *fakeNode) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	n.calls++
+	if n.down {
+		return nil, errors.New(n.name + " is down")
+	}
+
+	var logs []types.Log
+	for number := q.FromBlock.Uint64(); number <= q.ToBlock.Uint64() && number <= n.head; number++ {
+		logs = append(logs, types.Log{BlockNumber: number})
+	}
+
+	return logs, nil
+}
+
+func filterQuery(fromBlock, toBlock int64) ethereum.FilterQuery {
+	return ethereum.FilterQuery{FromBlock: big.NewInt(fromBlock), ToBlock: big.NewInt(toBlock)}
+}
+
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+	primary := &fakeNode{name: "primary", head: 100}
+	backup := &fakeNode{name: "backup", head: 95}
+
+	c, err := New(ctx, []Node{
+		{Name: backup.name, Priority: 1, Client: backup},
+		{Name: primary.name, Priority: 0, Client: primary},
+	}, Options{MaxErrorRate: 0.3, CheckInterval: -1})
+	if err != nil {
+		t.Fatal("unexpected New error", err.Error())
+	}
+
+	head, err := c.BlockNumber(ctx)
+	if err != nil || head != 100 || backup.calls != 0 {
+		t.Fatalf("expecting head 100 from primary, got %d (err %v, backup calls %d)", head, err, backup.calls)
+	}
+
+	// Fail over to backup, which is behind
+	primary.down = true
+	head, err = c.BlockNumber(ctx)
+	if err != nil {
+		t.Fatal("unexpected BlockNumber error after failover", err.Error())
+	}
+	if head != 100 {
+		t.Fatalf("head went backwards to %d after failover", head)
+	}
+
+	// Backup does not have blocks 96-100 yet, so it must not return empty results for them
+	if logs, err := c.FilterLogs(ctx, filterQuery(91, 100)); !errors.Is(err, ErrNoNode) {
+		t.Fatalf("expecting ErrNoNode for blocks beyond backup head, got %d logs (err %v)", len(logs), err)
+	}
+
+	if logs, err := c.FilterLogs(ctx, filterQuery(91, 95)); err != nil || len(logs) != 5 {
+		t.Fatalf("expecting 5 logs from backup, got %d logs (err %v)", len(logs), err)
+	}
+
+	// Primary has failed too often, so calls go to backup first even after it recovers
+	primary.down = false
+	primary.calls = 0
+	for i := 0; i < 3; i++ {
+		if _, err := c.FilterLogs(ctx, filterQuery(91, 95)); err != nil {
+			t.Fatal("unexpected FilterLogs error", err.Error())
+		}
+	}
+
+	if primary.calls != 0 {
+		t.Fatalf("expecting unhealthy primary to be skipped, got %d calls", primary.calls)
+	}
+
+	// Backup catches up, and its head is refreshed before the call
+	backup.head = 100
+	if logs, err := c.FilterLogs(ctx, filterQuery(91, 100)); err != nil || len(logs) != 10 {
+		t.Fatalf("expecting 10 logs from backup after it caught up, got %d logs (err %v)", len(logs), err)
+	}
+
+	// Health check sees primary recovered
+	c.(*client).check(ctx)
+	backup.calls = 0
+	if _, err := c.FilterLogs(ctx, filterQuery(91, 100)); err != nil || backup.calls != 0 {
+		t.Fatalf("expecting calls to go back to primary, got err %v and %d backup calls", err, backup.calls)
+	}
+
+	// All nodes down
+	primary.down, backup.down = true, true
+	if _, err := c.BlockNumber(ctx); !errors.Is(err, ErrNoNode) {
+		t.Fatalf("expecting ErrNoNode, got %v", err)
+	}
+}
+
+func TestFailoverHeadLag(t *testing.T) {
+	ctx := context.Background()
+	primary := &fakeNode{name: "primary", head: 100}
+	backup := &fakeNode{name: "backup", head: 120}
+
+	c, err := New(ctx, []Node{
+		{Name: primary.name, Priority: 0, Client: primary},
+		{Name: backup.name, Priority: 1, Client: backup},
+	}, Options{MaxHeadLag: 10, CheckInterval: -1})
+	if err != nil {
+		t.Fatal("unexpected New error", err.Error())
+	}
+
+	// Health check sees primary lagging 20 blocks behind backup
+	c.(*client).check(ctx)
+
+	head, err := c.BlockNumber(ctx)
+	if err != nil || head != 120 {
+		t.Fatalf("expecting head 120 from backup, got %d (err %v)", head, err)
+	}
+}
+
+func TestDial(t *testing.T) {
+	_, err := Dial(context.Background(), []superwatcher.NodeEndpoint{{URL: "foo://bar"}}, Options{})
+	if !errors.Is(err, ErrNoNode) {
+		t.Fatalf("expecting ErrNoNode from bad endpoints, got %v", err)
+	}
+}
+
+func TestBatchHead(t *testing.T) {
+	elems := []rpc.BatchElem{
+		{Method: batch.MethodGetBlockByNumber, Args: []interface{}{"0x64", false}},
+		{Method: batch.MethodGetBlockReceipts, Args: []interface{}{"0x65"}},
+		{Method: batch.MethodGetTransactionReceipt, Args: []interface{}{"0xff"}},
+	}
+
+	if head := batchHead(elems); head != 0x65 {
+		t.Fatalf("expecting batch head 101, got %d", head)
+	}
+}
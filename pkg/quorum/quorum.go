@@ -0,0 +1,375 @@
+package quorum
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+var ErrNoQuorum = errors.New("no quorum among nodes") // Not enough nodes agreed on a result
+
+// Disagreement describes a node whose result differs from the quorum's, or which failed.
+type Disagreement struct {
+	Method string // EthClient method, e.g. "FilterLogs"
+	Node   int    // Index of the node in the clients passed to New
+	Err    error  // Error from the node, nil if the node returned a result different from the quorum's
+}
+
+// Observer is called with every node disagreeing with the quorum. It may be called concurrently.
+type Observer func(Disagreement)
+
+// Config configures the quorum client.
+type Config struct {
+	// Quorum is the number of nodes that must agree on a result, 0 means a majority of queried nodes
+	Quorum int
+	// Subset is the number of nodes queried in each call, rotating through all nodes. 0 means all nodes
+	Subset int
+	// Observer is called with nodes disagreeing with the quorum, may be nil
+	Observer Observer
+}
+
+// client implements superwatcher.EthClient by querying several nodes, and only accepting results
+// that at least |quorum| nodes agree on. Block hashes, logs and batch call results must match exactly,
+// while block numbers are agreed on as the highest number at least |quorum| nodes have reached,
+// so that nodes lagging by a block or two do not break the quorum.
+type client struct {
+	clients  []superwatcher.EthClient
+	quorum   int
+	subset   int
+	observer Observer
+
+	next uint64 // Index of the first node in the next subset
+}
+
+// New returns superwatcher.EthClient that cross-checks |clients| as configured by |conf|.
+// It returns error if the quorum cannot be reached even if all queried nodes agree.
+func New(clients []superwatcher.EthClient, conf Config) (superwatcher.EthClient, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("no clients for quorum")
+	}
+
+	subset := conf.Subset
+	if subset <= 0 || subset > len(clients) {
+		subset = len(clients)
+	}
+
+	quorum := conf.Quorum
+	if quorum <= 0 {
+		quorum = subset/2 + 1
+	}
+
+	if quorum > subset {
+		return nil, errors.Errorf("quorum %d is larger than %d queried nodes", quorum, subset)
+	}
+
+	return &client{
+		clients:  clients,
+		quorum:   quorum,
+		subset:   subset,
+		observer: conf.Observer,
+	}, nil
+}
+
+// response is a result from a node, with key used to compare it with other nodes' results
+type response[T any] struct {
+	node   int
+	result T
+	key    string
+	err    error
+}
+
+// nodes returns the indexes of nodes to query in the next call
+func (c *client) nodes() []int {
+	start := 0
+	if c.subset < len(c.clients) {
+		start = int(atomic.AddUint64(&c.next, 1)-1) % len(c.clients)
+	}
+
+	nodes := make([]int, c.subset)
+	for i := range nodes {
+		nodes[i] = (start + i) % len(c.clients)
+	}
+
+	return nodes
+}
+
+// query calls |f| on the selected nodes concurrently
+func query[T any](
+	c *client,
+	f func(superwatcher.EthClient) (T, string, error),
+) []response[T] {
+	nodes := c.nodes()
+	responses := make([]response[T], len(nodes))
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i, node int) {
+			defer wg.Done()
+
+			result, key, err := f(c.clients[node])
+			responses[i] = response[T]{node: node, result: result, key: key, err: err}
+		}(i, node)
+	}
+
+	wg.Wait()
+	return responses
+}
+
+// agree returns the result at least c.quorum nodes agree on (by key),
+// and reports the other nodes to c.observer.
+func agree[T any](
+	c *client,
+	method string,
+	responses []response[T],
+) (
+	T,
+	error,
+) {
+	counts := make(map[string]int)
+	for _, r := range responses {
+		if r.err == nil {
+			counts[r.key]++
+		}
+	}
+
+	var agreed *response[T]
+	for i, r := range responses {
+		if r.err == nil && counts[r.key] >= c.quorum {
+			agreed = &responses[i]
+			break
+		}
+	}
+
+	if agreed == nil {
+		var zero T
+		report(c, method, responses, "")
+		return zero, errors.Wrapf(ErrNoQuorum, "%s: %d nodes needed, results %s", method, c.quorum, summary(responses))
+	}
+
+	report(c, method, responses, agreed.key)
+	return agreed.result, nil
+}
+
+// report calls c.observer with nodes whose results failed or differ from |key|
+func report[T any](
+	c *client,
+	method string,
+	responses []response[T],
+	key string,
+) {
+	if c.observer == nil {
+		return
+	}
+
+	for _, r := range responses {
+		if r.err == nil && r.key == key {
+			continue
+		}
+
+		c.observer(Disagreement{Method: method, Node: r.node, Err: r.err})
+	}
+}
+
+// summary counts the nodes per result key and error for error messages.
+// Node errors are included as is, so that callers can still inspect them, e.g. for FilterLogs range errors.
+func summary[T any](responses []response[T]) string {
+	counts := make(map[string]int)
+	for _, r := range responses {
+		if r.err != nil {
+			counts["error: "+r.err.Error()]++
+			continue
+		}
+
+		counts[r.key]++
+	}
+
+	return fmt.Sprintf("%v", counts)
+}
+
+// agreeNumber returns the highest block number at least c.quorum nodes have reached.
+// Nodes behind that number are reported as lagging.
+func (c *client) agreeNumber(method string, f func(superwatcher.EthClient) (uint64, error)) (uint64, error) {
+	responses := query(c, func(client superwatcher.EthClient) (uint64, string, error) {
+		n, err := f(client)
+		return n, "", err
+	})
+
+	var numbers []uint64
+	for _, r := range responses {
+		if r.err == nil {
+			numbers = append(numbers, r.result)
+		}
+	}
+
+	if len(numbers) < c.quorum {
+		report(c, method, responses, "")
+		return 0, errors.Wrapf(ErrNoQuorum, "%s: %d nodes needed, %d nodes responded", method, c.quorum, len(numbers))
+	}
+
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+	agreed := numbers[c.quorum-1]
+
+	for i := range responses {
+		if responses[i].err == nil && responses[i].result >= agreed {
+			responses[i].key = "agreed"
+		}
+	}
+
+	report(c, method, responses, "agreed")
+	return agreed, nil
+}
+
+func (c *client) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.agreeNumber("BlockNumber", func(client superwatcher.EthClient) (uint64, error) {
+		return client.BlockNumber(ctx)
+	})
+}
+
+func (c *client) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	return c.agreeNumber("SafeBlockNumber", func(client superwatcher.EthClient) (uint64, error) {
+		return client.SafeBlockNumber(ctx)
+	})
+}
+
+func (c *client) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return c.agreeNumber("FinalizedBlockNumber", func(client superwatcher.EthClient) (uint64, error) {
+		return client.FinalizedBlockNumber(ctx)
+	})
+}
+
+func (c *client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	responses := query(c, func(client superwatcher.EthClient) ([]types.Log, string, error) {
+		logs, err := client.FilterLogs(ctx, q)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return logs, logsKey(logs), nil
+	})
+
+	return agree(c, "FilterLogs", responses)
+}
+
+func (c *client) HeaderByNumber(ctx context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
+	responses := query(c, func(client superwatcher.EthClient) (superwatcher.BlockHeader, string, error) {
+		header, err := client.HeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return header, header.Hash().String(), nil
+	})
+
+	return agree(c, "HeaderByNumber", responses)
+}
+
+// BatchCallContext sends a copy of |elems| to each node, with new values of the same types as elems' Result.
+// The results and errors of the copy the quorum agreed on are then set to |elems|.
+func (c *client) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	responses := query(c, func(client superwatcher.EthClient) ([]rpc.BatchElem, string, error) {
+		copied := copyElems(elems)
+		if err := client.BatchCallContext(ctx, copied); err != nil {
+			return nil, "", err
+		}
+
+		key, err := elemsKey(copied)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return copied, key, nil
+	})
+
+	agreed, err := agree(c, "BatchCallContext", responses)
+	if err != nil {
+		return err
+	}
+
+	for i := range elems {
+		elems[i].Result = agreed[i].Result
+		elems[i].Error = agreed[i].Error
+	}
+
+	return nil
+}
+
+// SubscribeNewHead subscribes to the first node that supports subscriptions. New heads only wake callers up,
+// and the data polled after that is still cross-checked.
+func (c *client) SubscribeNewHead(ctx context.Context, ch chan<- superwatcher.BlockHeader) (ethereum.Subscription, error) {
+	var errs []string
+	for _, node := range c.nodes() {
+		sub, err := c.clients[node].SubscribeNewHead(ctx, ch)
+		if err == nil {
+			return sub, nil
+		}
+
+		errs = append(errs, err.Error())
+	}
+
+	return nil, errors.Errorf("no node supports newHeads subscription: %v", errs)
+}
+
+// logsKey identifies |logs| by their blocks, transactions and indexes
+func logsKey(logs []types.Log) string {
+	h := sha256.New()
+	for i := range logs {
+		fmt.Fprintf(h, "%s:%s:%d:%v;", logs[i].BlockHash.String(), logs[i].TxHash.String(), logs[i].Index, logs[i].Removed)
+	}
+
+	return fmt.Sprintf("%d logs %x", len(logs), h.Sum(nil))
+}
+
+// elemsKey identifies batch call results. Block headers are identified by hashes,
+// and other results by their JSON encoding.
+func elemsKey(elems []rpc.BatchElem) (string, error) {
+	h := sha256.New()
+	for i, elem := range elems {
+		if elem.Error != nil {
+			fmt.Fprintf(h, "error %s;", elem.Error.Error())
+			continue
+		}
+
+		if header, ok := elem.Result.(superwatcher.BlockHeader); ok {
+			fmt.Fprintf(h, "%s;", header.Hash().String())
+			continue
+		}
+
+		b, err := json.Marshal(elem.Result)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to marshal result of elems[%d]", i)
+		}
+
+		h.Write(b)
+		h.Write([]byte(";"))
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// copyElems copies |elems| with new zero values of the same types as their Result
+func copyElems(elems []rpc.BatchElem) []rpc.BatchElem {
+	copied := make([]rpc.BatchElem, len(elems))
+	for i, elem := range elems {
+		copied[i] = rpc.BatchElem{Method: elem.Method, Args: elem.Args}
+
+		if t := reflect.TypeOf(elem.Result); t != nil && t.Kind() == reflect.Pointer {
+			copied[i].Result = reflect.New(t.Elem()).Interface()
+		}
+	}
+
+	return copied
+}
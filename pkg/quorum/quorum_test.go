@@ -0,0 +1,147 @@
+package quorum
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/internal/poller"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+func init() {
+	testlogs.SetLogsPath("../../testlogs")
+}
+
+// newDivergentSims returns 3 ReorgSim clients for testlogs.TestCasesV1[0]. The first 2 never reorg
+// in the test range, while the last one is reorged at the test case's reorg block on its 2nd FilterLogs call.
+func newDivergentSims(t *testing.T) ([]superwatcher.EthClient, *testlogs.TestConfig) {
+	tc := testlogs.TestCasesV1[0]
+	logs := reorgsim.InitMappedLogsFromFiles(tc.LogsFiles...)
+
+	var clients []superwatcher.EthClient
+	for i, event := range []reorgsim.ReorgEvent{{ReorgBlock: tc.ToBlock + 1000}, {ReorgBlock: tc.ToBlock + 1000}, tc.Events[0]} {
+		events := []reorgsim.ReorgEvent{event}
+		chain, reorgedChains := reorgsim.NewBlockChain(logs, events)
+		sim, err := reorgsim.NewReorgSim(tc.Param, events, chain, reorgedChains, "", 1)
+		if err != nil {
+			t.Fatalf("cannot init ReorgSim %d: %s", i, err.Error())
+		}
+
+		clients = append(clients, sim)
+	}
+
+	return clients, tc
+}
+
+type observed struct {
+	sync.Mutex
+	nodes map[int]int
+}
+
+func (o *observed) observe(d Disagreement) {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.nodes == nil {
+		o.nodes = make(map[int]int)
+	}
+
+	o.nodes[d.Node]++
+}
+
+func TestQuorum(t *testing.T) {
+	clients, tc := newDivergentSims(t)
+	ctx := context.Background()
+	q := ethereum.FilterQuery{
+		FromBlock: big.NewInt(int64(tc.FromBlock)),
+		ToBlock:   big.NewInt(int64(tc.ToBlock)),
+	}
+
+	// Fork the last node
+	for i := 0; i < 2; i++ {
+		if _, err := clients[2].FilterLogs(ctx, q); err != nil {
+			t.Fatal("unexpected FilterLogs error", err.Error())
+		}
+	}
+
+	o := new(observed)
+	client, err := New(clients, Config{Quorum: 2, Observer: o.observe})
+	if err != nil {
+		t.Fatal("unexpected New error", err.Error())
+	}
+
+	logs, err := client.FilterLogs(ctx, q)
+	if err != nil {
+		t.Fatal("unexpected FilterLogs error", err.Error())
+	}
+
+	expected, _ := clients[0].FilterLogs(ctx, q)
+	if logsKey(logs) != logsKey(expected) {
+		t.Fatal("unexpected logs from quorum")
+	}
+
+	reorgBlock := big.NewInt(int64(tc.Events[0].ReorgBlock))
+	header, err := client.HeaderByNumber(ctx, reorgBlock)
+	if err != nil {
+		t.Fatal("unexpected HeaderByNumber error", err.Error())
+	}
+
+	expectedHeader, _ := clients[0].HeaderByNumber(ctx, reorgBlock)
+	if header.Hash() != expectedHeader.Hash() {
+		t.Fatal("unexpected header hash from quorum")
+	}
+
+	if len(o.nodes) != 1 || o.nodes[2] != 2 {
+		t.Fatalf("expecting 2 disagreements from node 2, got %v", o.nodes)
+	}
+
+	// The forked node breaks unanimity
+	client, err = New(clients, Config{Quorum: 3})
+	if err != nil {
+		t.Fatal("unexpected New error", err.Error())
+	}
+
+	if _, err := client.FilterLogs(ctx, q); !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("expecting ErrNoQuorum, got %v", err)
+	}
+
+	if _, err := New(clients, Config{Quorum: 3, Subset: 2}); err == nil {
+		t.Fatal("expecting error from quorum larger than subset")
+	}
+}
+
+// TestQuorumPoller checks that a poller using the quorum client does not see phantom reorgs from a divergent node.
+func TestQuorumPoller(t *testing.T) {
+	clients, tc := newDivergentSims(t)
+
+	o := new(observed)
+	client, err := New(clients, Config{Observer: o.observe})
+	if err != nil {
+		t.Fatal("unexpected New error", err.Error())
+	}
+
+	p := poller.New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyNormal)
+
+	// The 2nd poll would see the reorged chain from the last node
+	for i := 0; i < 2; i++ {
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatalf("unexpected error from poll %d: %s", i, err.Error())
+		}
+
+		if len(result.ReorgedBlocks) != 0 {
+			t.Fatalf("unexpected reorged blocks from poll %d", i)
+		}
+	}
+
+	if o.nodes[2] == 0 || o.nodes[0]+o.nodes[1] != 0 {
+		t.Fatalf("expecting disagreements only from node 2, got %v", o.nodes)
+	}
+}
@@ -13,6 +13,7 @@ import (
 type Block struct {
 	blockNumber uint64
 	hash        common.Hash
+	parentHash  common.Hash // parentHash is only filled when the block is served by ReorgSim
 	logs        []types.Log
 
 	reorgedHere bool // reorgedHere marks if this block is where an ReorgEvent begins
@@ -30,6 +31,12 @@ func (b *Block) Hash() common.Hash {
 	return b.hash
 }
 
+// ParentHash mocks field *types.Header.ParentHash.
+// It is the hash of block b.blockNumber-1 in the chain from which b was served.
+func (b *Block) ParentHash() common.Hash {
+	return b.parentHash
+}
+
 func (b *Block) Logs() []types.Log {
 	return b.logs
 }
@@ -2,4 +2,7 @@ package reorgsim
 
 import "errors"
 
-var ErrExitBlockReached = errors.New("exitBlock reached for reorgsim")
+var (
+	ErrExitBlockReached = errors.New("exitBlock reached for reorgsim")
+	ErrHeadsDropped     = errors.New("newHeads subscription dropped by reorgsim") // Param.HeadsBeforeDrop reached
+)
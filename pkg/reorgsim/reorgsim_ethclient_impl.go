@@ -5,11 +5,13 @@ package reorgsim
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -23,6 +25,11 @@ func (r *ReorgSim) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (
 	r.Lock()
 	defer r.Unlock()
 
+	// EIP-234 block hash queries do not trigger chain reorg
+	if query.BlockHash != nil {
+		return r.filterLogsByBlockHash(*query.BlockHash, query)
+	}
+
 	if query.FromBlock == nil {
 		return nil, errors.New("nil query.FromBlock")
 	}
@@ -68,6 +75,23 @@ func (r *ReorgSim) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (
 	return logs, nil
 }
 
+// filterLogsByBlockHash returns logs from the block with |hash| in r.currentChain.
+// Like real nodes, it returns an error if |hash| is unknown, e.g. the block was reorged.
+func (r *ReorgSim) filterLogsByBlockHash(hash common.Hash, query ethereum.FilterQuery) ([]types.Log, error) {
+	for _, b := range r.currentChain {
+		if b.hash != hash {
+			continue
+		}
+
+		var logs []types.Log
+		appendFilterLogs(&b.logs, &logs, query.Addresses, query.Topics)
+
+		return logs, nil
+	}
+
+	return nil, errors.Errorf("unknown block %s", hash.String())
+}
+
 func (r *ReorgSim) BlockNumber(ctx context.Context) (uint64, error) {
 	r.Lock()
 	defer r.Unlock()
@@ -87,78 +111,182 @@ func (r *ReorgSim) BlockNumber(ctx context.Context) (uint64, error) {
 }
 
 func (r *ReorgSim) HeaderByNumber(ctx context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
-	blockNumber := number.Uint64()
-
-	b, ok := r.currentChain[blockNumber]
-	if !ok {
-		event := r.events[r.currentReorgEvent]
-		toBeForked := blockNumber >= event.ReorgBlock
-		reorgedHere := blockNumber == event.ReorgBlock
-
-		var h common.Hash
-		if toBeForked {
-			h = ReorgHash(blockNumber, r.currentReorgEvent)
-		} else {
-			h = PRandomHash(blockNumber)
-		}
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.blockByNumber(number.Uint64()), nil
+}
 
-		b = &Block{
-			hash:        h,
-			blockNumber: blockNumber,
-			reorgedHere: reorgedHere,
-			toBeForked:  toBeForked,
-			logs:        nil,
+// SafeBlockNumber returns ReorgSim.currentBlock - Param.SafeDepth
+func (r *ReorgSim) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	return r.taggedBlockNumber("safe", r.param.SafeDepth)
+}
+
+// FinalizedBlockNumber returns ReorgSim.currentBlock - Param.FinalizedDepth
+func (r *ReorgSim) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return r.taggedBlockNumber("finalized", r.param.FinalizedDepth)
+}
+
+// SubscribeNewHead sends the header of ReorgSim.currentBlock to |ch| every Param.HeadInterval.
+// Like the real newHeads feed, it does not move the chain forward - only BlockNumber does.
+// The subscription fails with ErrHeadsDropped after Param.HeadsBeforeDrop heads.
+func (r *ReorgSim) SubscribeNewHead(ctx context.Context, ch chan<- superwatcher.BlockHeader) (ethereum.Subscription, error) {
+	if r.param.HeadInterval == 0 {
+		return nil, errors.New("notifications not supported")
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(r.param.HeadInterval)
+		defer ticker.Stop()
+
+		var sent uint64
+		for {
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return nil
+			}
+
+			if r.param.HeadsBeforeDrop != 0 && sent >= r.param.HeadsBeforeDrop {
+				return errors.Wrapf(ErrHeadsDropped, "dropped after %d heads", sent)
+			}
+
+			r.RLock()
+			currentBlock := r.currentBlock
+			if currentBlock == 0 {
+				currentBlock = r.param.StartBlock
+			}
+			head := r.blockByNumber(currentBlock)
+			r.RUnlock()
+
+			select {
+			case ch <- head:
+				sent++
+			case <-quit:
+				return nil
+			}
 		}
+	}), nil
+}
+
+// taggedBlockNumber returns the block |depth| blocks behind r.currentBlock, or Param.StartBlock
+// if BlockNumber was never called. It returns error if |depth| is 0, i.e. the tag is not supported.
+func (r *ReorgSim) taggedBlockNumber(tag string, depth uint64) (uint64, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if depth == 0 {
+		return 0, errors.Errorf("%s block not found", tag)
+	}
+
+	currentBlock := r.currentBlock
+	if currentBlock == 0 {
+		currentBlock = r.param.StartBlock
 	}
 
-	return b, nil
+	if currentBlock < depth {
+		return 0, nil
+	}
+
+	return currentBlock - depth, nil
 }
 
-// BatchCallContext only processes `"eth_getBlockByNumber" RPC method calls.
-// Each elem.Result in elems will be overwritten with *Block (implements superwatcher.BlockHeader)
-// from the current chain.
+// BatchCallContext only processes `eth_getBlockByNumber`, `eth_getBlockReceipts`, and `eth_getTransactionReceipt` RPC method calls.
+// For `eth_getBlockByNumber`, each elem.Result in elems will be overwritten with *Block (implements superwatcher.BlockHeader)
+// from the current chain. For `eth_getBlockReceipts`, elem.Result will be overwritten with []*types.Receipt,
+// and for `eth_getTransactionReceipt`, elem.Result will be overwritten with *types.Receipt (nil if not found).
 func (r *ReorgSim) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
 	r.RLock()
 	defer r.RUnlock()
 
 	for i, elem := range elems {
-		if elem.Method != batch.MethodGetBlockByNumber {
-			continue
-		}
+		switch elem.Method {
+		case batch.MethodGetBlockByNumber:
+			// Get blockNumber from the first string argument
+			bn, err := hexutil.DecodeBig(elem.Args[0].(string))
+			if err != nil {
+				return errors.Wrapf(err, "elems[%d] has invalid argument for method %s", i, elem.Method)
+			}
 
-		// Get blockNumber from the first string argument
-		bn, err := hexutil.DecodeBig(elem.Args[0].(string))
-		if err != nil {
-			return errors.Wrapf(err, "elems[%d] has invalid argument for method %s", i, batch.MethodGetBlockByNumber)
-		}
+			elems[i].Result = r.blockByNumber(bn.Uint64())
+
+		case batch.MethodGetBlockReceipts:
+			if r.param.NoBlockReceipts {
+				elems[i].Error = errors.Errorf("the method %s does not exist/is not available", elem.Method)
+				continue
+			}
 
-		number := bn.Uint64()
-		b, ok := r.currentChain[number]
-		if !ok {
-			eventIndex := r.currentReorgEvent
-			if lenEvents := len(r.events); r.currentReorgEvent >= lenEvents {
-				eventIndex = lenEvents - 1
+			bn, err := hexutil.DecodeBig(elem.Args[0].(string))
+			if err != nil {
+				return errors.Wrapf(err, "elems[%d] has invalid argument for method %s", i, elem.Method)
 			}
-			event := r.events[eventIndex]
-			toBeForked := number >= event.ReorgBlock
-
-			var hash common.Hash
-			if toBeForked {
-				hash = ReorgHash(number, r.currentReorgEvent)
-			} else {
-				hash = PRandomHash(number)
+
+			elems[i].Result = r.blockByNumber(bn.Uint64()).Receipts()
+
+		case batch.MethodGetTransactionReceipt:
+			txHash, ok := elem.Args[0].(common.Hash)
+			if !ok {
+				return errors.Errorf("elems[%d] has invalid argument for method %s", i, elem.Method)
 			}
 
-			b = &Block{
-				blockNumber: number,
-				hash:        hash,
-				toBeForked:  toBeForked,
-				reorgedHere: number == event.ReorgBlock,
+			var receipt *types.Receipt
+			for _, b := range r.currentChain {
+				if receipt = b.receipt(txHash); receipt != nil {
+					break
+				}
 			}
-		}
 
-		elems[i].Result = b
+			elems[i].Result = receipt
+		}
 	}
 
 	return nil
 }
+
+// blockByNumber returns a copy of block |number| from r.currentChain, with parentHash set to
+// the hash of block |number|-1 from the same chain. If the block is not in r.currentChain,
+// an empty block is returned. The copy is returned so that the chains' blocks are never mutated.
+func (r *ReorgSim) blockByNumber(number uint64) *Block {
+	b := *r.chainBlock(number)
+	if number != 0 {
+		b.parentHash = r.chainBlock(number - 1).hash
+	}
+
+	return &b
+}
+
+// chainBlock returns block |number| from r.currentChain. If the block is not in r.currentChain,
+// chainBlock returns an empty block whose hash depends on the current ReorgEvent.
+func (r *ReorgSim) chainBlock(number uint64) *Block {
+	if b, ok := r.currentChain[number]; ok {
+		return b
+	}
+
+	if len(r.events) == 0 {
+		return &Block{
+			blockNumber: number,
+			hash:        PRandomHash(number),
+		}
+	}
+
+	eventIndex := r.currentReorgEvent
+	if lenEvents := len(r.events); r.currentReorgEvent >= lenEvents {
+		eventIndex = lenEvents - 1
+	}
+	event := r.events[eventIndex]
+	toBeForked := number >= event.ReorgBlock
+
+	var hash common.Hash
+	if toBeForked {
+		hash = ReorgHash(number, r.currentReorgEvent)
+	} else {
+		hash = PRandomHash(number)
+	}
+
+	return &Block{
+		blockNumber: number,
+		hash:        hash,
+		toBeForked:  toBeForked,
+		reorgedHere: number == event.ReorgBlock,
+	}
+}
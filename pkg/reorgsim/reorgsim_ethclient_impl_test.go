@@ -11,6 +11,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
 )
 
 func TestFilterLogs(t *testing.T) {
@@ -52,6 +54,50 @@ func TestFilterLogs(t *testing.T) {
 	}
 }
 
+func TestFilterLogsBlockHash(t *testing.T) {
+	param := Param{
+		StartBlock:    defaultStartBlock,
+		BlockProgress: 20,
+	}
+
+	sim, err := NewReorgSimFromLogsFiles(param, []ReorgEvent{{ReorgBlock: defaultReorgedAt}}, defaultLogsFiles, "TestFilterLogsBlockHash", 4)
+	if err != nil {
+		t.Fatal("error creating ReorgSim", err.Error())
+	}
+
+	ctx := context.Background()
+	logs, err := sim.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: big.NewInt(10000000),
+		ToBlock:   big.NewInt(16000000),
+	})
+	if err != nil {
+		t.Fatal("FilterLogs returned error", err.Error())
+	}
+	if len(logs) == 0 {
+		t.Fatal("expecting > 0 logs, got 0 log")
+	}
+
+	hash := logs[0].BlockHash
+	blockLogs, err := sim.FilterLogs(ctx, ethereum.FilterQuery{BlockHash: &hash})
+	if err != nil {
+		t.Fatal("FilterLogs with blockHash returned error", err.Error())
+	}
+	if len(blockLogs) == 0 {
+		t.Fatal("expecting > 0 logs from blockHash query, got 0 log")
+	}
+
+	for _, log := range blockLogs {
+		if log.BlockHash != hash || log.BlockNumber != logs[0].BlockNumber {
+			t.Fatalf("unexpected log from block %d in blockHash query", log.BlockNumber)
+		}
+	}
+
+	unknown := common.BigToHash(big.NewInt(69))
+	if _, err := sim.FilterLogs(ctx, ethereum.FilterQuery{BlockHash: &unknown}); err == nil {
+		t.Fatal("expecting error from unknown blockHash")
+	}
+}
+
 func TestFilterLogsReorg(t *testing.T) {
 	for i, test := range testsReorgSim {
 		testFilterLogsReorg(t, i+1, test)
@@ -146,3 +192,167 @@ func testFilterLogsReorg(t *testing.T, caseNumber int, testConf multiReorgConfig
 
 	return nil
 }
+
+func TestParentHash(t *testing.T) {
+	param := Param{
+		StartBlock:    defaultStartBlock,
+		BlockProgress: 20,
+	}
+	event := ReorgEvent{
+		ReorgBlock: defaultReorgedAt,
+		MovedLogs:  nil,
+	}
+
+	sim, err := NewReorgSimFromLogsFiles(param, []ReorgEvent{event}, defaultLogsFiles, "TestParentHash", 4)
+	if err != nil {
+		t.Fatal("error creating ReorgSim", err.Error())
+	}
+
+	ctx := context.Background()
+	fromBlock, toBlock := defaultReorgedAt-20, defaultReorgedAt+20
+
+	// getHashes gets block hashes in range, and checks that the blocks link to each other via parent hashes
+	getHashes := func() map[uint64]common.Hash {
+		hashes := make(map[uint64]common.Hash)
+		for n := fromBlock; n <= toBlock; n++ {
+			header, err := sim.HeaderByNumber(ctx, big.NewInt(int64(n)))
+			if err != nil {
+				t.Fatalf("HeaderByNumber returned error: %s", err.Error())
+			}
+
+			if prev, ok := hashes[n-1]; ok && header.ParentHash() != prev {
+				t.Fatalf("block %d parentHash %s does not match block %d hash %s", n, header.ParentHash().String(), n-1, prev.String())
+			}
+
+			hashes[n] = header.Hash()
+		}
+
+		return hashes
+	}
+
+	oldHashes := getHashes()
+
+	// Trigger and fork the chain
+	for i := 0; i < 2; i++ {
+		if _, err := sim.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: big.NewInt(int64(fromBlock)),
+			ToBlock:   big.NewInt(int64(toBlock)),
+		}); err != nil {
+			t.Fatalf("FilterLogs returned error: %s", err.Error())
+		}
+	}
+
+	newHashes := getHashes()
+	for n := fromBlock; n <= toBlock; n++ {
+		if n < defaultReorgedAt && oldHashes[n] != newHashes[n] {
+			t.Fatalf("block %d is before reorg block %d, but its hash changed", n, defaultReorgedAt)
+		}
+		if n >= defaultReorgedAt && oldHashes[n] == newHashes[n] {
+			t.Fatalf("block %d is after reorg block %d, but its hash did not change", n, defaultReorgedAt)
+		}
+	}
+}
+
+func TestTaggedBlockNumbers(t *testing.T) {
+	param := Param{
+		StartBlock:     defaultStartBlock,
+		BlockProgress:  20,
+		ExitBlock:      defaultStartBlock + 1000,
+		SafeDepth:      10,
+		FinalizedDepth: 50,
+	}
+
+	sim, err := NewReorgSimFromLogsFiles(param, nil, defaultLogsFiles, "TestTaggedBlockNumbers", 4)
+	if err != nil {
+		t.Fatal("error creating ReorgSim", err.Error())
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		currentBlock, err := sim.BlockNumber(ctx)
+		if err != nil {
+			t.Fatal("unexpected BlockNumber error", err.Error())
+		}
+
+		safe, err := sim.SafeBlockNumber(ctx)
+		if err != nil {
+			t.Fatal("unexpected SafeBlockNumber error", err.Error())
+		}
+
+		finalized, err := sim.FinalizedBlockNumber(ctx)
+		if err != nil {
+			t.Fatal("unexpected FinalizedBlockNumber error", err.Error())
+		}
+
+		// BlockNumber increments ReorgSim.currentBlock after returning
+		head := currentBlock
+		if i != 0 {
+			head += param.BlockProgress
+		}
+
+		if expected := head - param.SafeDepth; safe != expected {
+			t.Fatalf("expecting safe block %d, got %d", expected, safe)
+		}
+		if expected := head - param.FinalizedDepth; finalized != expected {
+			t.Fatalf("expecting finalized block %d, got %d", expected, finalized)
+		}
+	}
+
+	param.FinalizedDepth = 0
+	sim, err = NewReorgSimFromLogsFiles(param, nil, defaultLogsFiles, "TestTaggedBlockNumbers", 4)
+	if err != nil {
+		t.Fatal("error creating ReorgSim", err.Error())
+	}
+
+	if _, err := sim.FinalizedBlockNumber(ctx); err == nil {
+		t.Fatal("expecting error from ReorgSim without FinalizedDepth")
+	}
+}
+
+func TestSubscribeNewHead(t *testing.T) {
+	param := Param{
+		StartBlock:      defaultStartBlock,
+		BlockProgress:   20,
+		HeadInterval:    time.Millisecond,
+		HeadsBeforeDrop: 3,
+	}
+
+	sim, err := NewReorgSimFromLogsFiles(param, []ReorgEvent{{ReorgBlock: defaultReorgedAt}}, defaultLogsFiles, "TestSubscribeNewHead", 4)
+	if err != nil {
+		t.Fatal("error creating ReorgSim", err.Error())
+	}
+
+	heads := make(chan superwatcher.BlockHeader)
+	sub, err := sim.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatal("unexpected SubscribeNewHead error", err.Error())
+	}
+	defer sub.Unsubscribe()
+
+	for i := 0; i < int(param.HeadsBeforeDrop); i++ {
+		select {
+		case head := <-heads:
+			if head.Number() != param.StartBlock {
+				t.Fatalf("expecting head %d, got %d", param.StartBlock, head.Number())
+			}
+
+		case err := <-sub.Err():
+			t.Fatalf("unexpected subscription error after %d heads: %v", i, err)
+		}
+	}
+
+	if err := <-sub.Err(); !errors.Is(err, ErrHeadsDropped) {
+		t.Fatalf("expecting ErrHeadsDropped, got %v", err)
+	}
+
+	// Like HTTP endpoints without subscriptions
+	param.HeadInterval = 0
+	sim, err = NewReorgSimFromLogsFiles(param, []ReorgEvent{{ReorgBlock: defaultReorgedAt}}, defaultLogsFiles, "TestSubscribeNewHead", 4)
+	if err != nil {
+		t.Fatal("error creating ReorgSim", err.Error())
+	}
+
+	if _, err := sim.SubscribeNewHead(context.Background(), heads); err == nil {
+		t.Fatal("expecting error from SubscribeNewHead without HeadInterval")
+	}
+}
@@ -1,6 +1,10 @@
 package reorgsim
 
-import "github.com/pkg/errors"
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
 
 // Param is the basic parameters for the mock client. Chain reorg parameters are NOT included here.
 type Param struct {
@@ -11,6 +15,21 @@ type Param struct {
 	// ExitBlock is checked against ReorgSim.currentBlock for test code to exit at a specify block.
 	ExitBlock uint64 `json:"exitBlock"`
 
+	// NoBlockReceipts makes ReorgSim reject `eth_getBlockReceipts` calls like some nodes do,
+	// so that callers have to fall back to `eth_getTransactionReceipt`.
+	NoBlockReceipts bool `json:"noBlockReceipts"`
+
+	// SafeDepth and FinalizedDepth are the number of blocks the `safe` and `finalized` blocks
+	// are behind ReorgSim.currentBlock. If 0, ReorgSim returns errors like pre-merge nodes.
+	SafeDepth      uint64 `json:"safeDepth"`
+	FinalizedDepth uint64 `json:"finalizedDepth"`
+
+	// HeadInterval is the interval between fake `newHeads` notifications sent to SubscribeNewHead subscribers.
+	// If 0, SubscribeNewHead returns errors like nodes without subscription support.
+	HeadInterval time.Duration `json:"headInterval"`
+	// HeadsBeforeDrop makes SubscribeNewHead subscriptions fail after sending this many heads, 0 means never.
+	HeadsBeforeDrop uint64 `json:"headsBeforeDrop"`
+
 	Debug bool `json:"-"`
 }
 
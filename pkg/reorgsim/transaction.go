@@ -0,0 +1,105 @@
+package reorgsim
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/soyart/superwatcher"
+)
+
+// Transactions mocks the full transaction objects returned by `eth_getBlockByNumber(n, true)`.
+// The transactions are derived from b.logs, one transaction per unique log.TxHash,
+// so that the mocked transactions move together with their logs during chain reorgs.
+func (b *Block) Transactions() []*superwatcher.Transaction {
+	seen := make(map[common.Hash]bool)
+
+	var txs []*superwatcher.Transaction
+	for i := range b.logs {
+		log := &b.logs[i]
+		if seen[log.TxHash] {
+			continue
+		}
+
+		seen[log.TxHash] = true
+		txs = append(txs, mockTransaction(log))
+	}
+
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Index < txs[j].Index
+	})
+
+	return txs
+}
+
+// mockTransaction returns a deterministic transaction for |log|.
+// The sender and value are derived from log.TxHash, and the calldata is log.Data.
+func mockTransaction(log *types.Log) *superwatcher.Transaction {
+	to := log.Address
+
+	return &superwatcher.Transaction{
+		Hash:  log.TxHash,
+		From:  common.BytesToAddress(log.TxHash.Bytes()),
+		Index: log.TxIndex,
+		Tx: types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(log.TxIndex),
+			To:       &to,
+			Value:    new(big.Int).SetBytes(log.TxHash[:8]),
+			Gas:      21000,
+			GasPrice: big.NewInt(1),
+			Data:     log.Data,
+		}),
+	}
+}
+
+// Receipts mocks the receipts returned by `eth_getBlockReceipts`, one receipt for each transaction
+// in b.Transactions(). Each receipt has the block's logs emitted by the transaction.
+func (b *Block) Receipts() []*types.Receipt {
+	txs := b.Transactions()
+	receipts := make([]*types.Receipt, len(txs))
+
+	var cumulativeGasUsed uint64
+	for i, tx := range txs {
+		cumulativeGasUsed += tx.Tx.Gas()
+		receipts[i] = b.mockReceipt(tx, cumulativeGasUsed)
+	}
+
+	return receipts
+}
+
+// receipt returns the mocked receipt for transaction |txHash|, or nil if the transaction is not in b.
+func (b *Block) receipt(txHash common.Hash) *types.Receipt {
+	for _, receipt := range b.Receipts() {
+		if receipt.TxHash == txHash {
+			return receipt
+		}
+	}
+
+	return nil
+}
+
+// mockReceipt returns a deterministic, successful receipt for |tx|.
+func (b *Block) mockReceipt(tx *superwatcher.Transaction, cumulativeGasUsed uint64) *types.Receipt {
+	var logs []*types.Log
+	for i := range b.logs {
+		if b.logs[i].TxHash == tx.Hash {
+			log := b.logs[i]
+			logs = append(logs, &log)
+		}
+	}
+
+	return &types.Receipt{
+		Type:              tx.Tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: cumulativeGasUsed,
+		Logs:              logs,
+		TxHash:            tx.Hash,
+		GasUsed:           tx.Tx.Gas(),
+		EffectiveGasPrice: tx.Tx.GasPrice(),
+		BlockHash:         b.hash,
+		BlockNumber:       new(big.Int).SetUint64(b.blockNumber),
+		TransactionIndex:  tx.Index,
+	}
+}
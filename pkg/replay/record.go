@@ -0,0 +1,119 @@
+package replay
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+// Record is a recorded EthClient call, written as a line of JSON.
+type Record struct {
+	Seq      uint64          `json:"seq"`                // Order in which the call returned
+	Method   string          `json:"method"`             // EthClient method, e.g. "FilterLogs"
+	Request  json.RawMessage `json:"request,omitempty"`  // Call arguments, used to match calls during replay
+	Response json.RawMessage `json:"response,omitempty"` // Call results
+	Error    string          `json:"error,omitempty"`    // Error message, if the call failed
+}
+
+// key identifies calls with the same method and arguments
+func (r *Record) key() string {
+	return r.Method + string(r.Request)
+}
+
+// batchRequest is the recorded request of a batch element
+type batchRequest struct {
+	Method string        `json:"method"`
+	Args   []interface{} `json:"args"`
+}
+
+// batchResult is the recorded result of a batch element. Results implementing superwatcher.BlockHeader,
+// e.g. *reorgsim.Block, are recorded as Header, and other results as their JSON encoding.
+type batchResult struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Header *header         `json:"header,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// header is a recorded superwatcher.BlockHeader
+type header struct {
+	BlockNumber     uint64           `json:"number"`
+	BlockHash       common.Hash      `json:"hash"`
+	BlockParentHash common.Hash      `json:"parentHash"`
+	BlockNonce      types.BlockNonce `json:"nonce"`
+	BlockTime       uint64           `json:"timestamp"`
+	BlockGasLimit   uint64           `json:"gasLimit"`
+	BlockGasUsed    uint64           `json:"gasUsed"`
+}
+
+func newHeader(h superwatcher.BlockHeader) *header {
+	return &header{
+		BlockNumber:     h.Number(),
+		BlockHash:       h.Hash(),
+		BlockParentHash: h.ParentHash(),
+		BlockNonce:      h.Nonce(),
+		BlockTime:       h.Time(),
+		BlockGasLimit:   h.GasLimit(),
+		BlockGasUsed:    h.GasUsed(),
+	}
+}
+
+func (h *header) Number() uint64 {
+	return h.BlockNumber
+}
+
+func (h *header) Hash() common.Hash {
+	return h.BlockHash
+}
+
+func (h *header) ParentHash() common.Hash {
+	return h.BlockParentHash
+}
+
+func (h *header) Nonce() types.BlockNonce {
+	return h.BlockNonce
+}
+
+func (h *header) Time() uint64 {
+	return h.BlockTime
+}
+
+func (h *header) GasLimit() uint64 {
+	return h.BlockGasLimit
+}
+
+func (h *header) GasUsed() uint64 {
+	return h.BlockGasUsed
+}
+
+// filterLogsRequest returns the recorded request of FilterLogs
+func filterLogsRequest(q ethereum.FilterQuery) (json.RawMessage, error) {
+	b, err := json.Marshal(q)
+	return b, errors.Wrap(err, "failed to marshal FilterLogs query")
+}
+
+// headerByNumberRequest returns the recorded request of HeaderByNumber
+func headerByNumberRequest(number *big.Int) (json.RawMessage, error) {
+	if number == nil {
+		return json.Marshal("latest")
+	}
+
+	return json.Marshal(number.String())
+}
+
+// batchCallRequest returns the recorded request of BatchCallContext
+func batchCallRequest(elems []rpc.BatchElem) (json.RawMessage, error) {
+	requests := make([]batchRequest, len(elems))
+	for i := range elems {
+		requests[i] = batchRequest{Method: elems[i].Method, Args: elems[i].Args}
+	}
+
+	b, err := json.Marshal(requests)
+	return b, errors.Wrap(err, "failed to marshal batch elems")
+}
@@ -0,0 +1,150 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+// recorder implements superwatcher.EthClient by passing calls to |client|,
+// and writing each call's request and response to |w| as a Record.
+type recorder struct {
+	sync.Mutex
+
+	client  superwatcher.EthClient
+	encoder *json.Encoder
+	seq     uint64
+}
+
+// NewRecorder returns superwatcher.EthClient that records all calls to |client| to |w|, one Record per line.
+// If a call cannot be recorded, it returns the recording error, even if the call itself succeeded.
+// SubscribeNewHead is passed through unrecorded, since new heads only wake the emitter up.
+func NewRecorder(client superwatcher.EthClient, w io.Writer) superwatcher.EthClient {
+	return &recorder{
+		client:  client,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// record writes a Record with |response| and |callErr|, and returns |callErr| or the recording error
+func (r *recorder) record(
+	method string,
+	request json.RawMessage,
+	response interface{},
+	callErr error,
+) error {
+	rec := Record{Method: method, Request: request}
+	if callErr != nil {
+		rec.Error = callErr.Error()
+	}
+
+	if response != nil {
+		b, err := json.Marshal(response)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s response", method)
+		}
+
+		rec.Response = b
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	rec.Seq = r.seq
+	r.seq++
+
+	if err := r.encoder.Encode(rec); err != nil {
+		return errors.Wrapf(err, "failed to record %s", method)
+	}
+
+	return callErr
+}
+
+func (r *recorder) BlockNumber(ctx context.Context) (uint64, error) {
+	n, err := r.client.BlockNumber(ctx)
+	return n, r.record("BlockNumber", nil, n, err)
+}
+
+func (r *recorder) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	n, err := r.client.SafeBlockNumber(ctx)
+	return n, r.record("SafeBlockNumber", nil, n, err)
+}
+
+func (r *recorder) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	n, err := r.client.FinalizedBlockNumber(ctx)
+	return n, r.record("FinalizedBlockNumber", nil, n, err)
+}
+
+func (r *recorder) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	request, err := filterLogsRequest(q)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := r.client.FilterLogs(ctx, q)
+	if err != nil {
+		return nil, r.record("FilterLogs", request, nil, err)
+	}
+
+	return logs, r.record("FilterLogs", request, logs, nil)
+}
+
+func (r *recorder) HeaderByNumber(ctx context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
+	request, err := headerByNumberRequest(number)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := r.client.HeaderByNumber(ctx, number)
+	if err != nil || h == nil {
+		return h, r.record("HeaderByNumber", request, nil, err)
+	}
+
+	return h, r.record("HeaderByNumber", request, newHeader(h), nil)
+}
+
+func (r *recorder) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	request, err := batchCallRequest(elems)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.BatchCallContext(ctx, elems); err != nil {
+		return r.record("BatchCallContext", request, nil, err)
+	}
+
+	results := make([]batchResult, len(elems))
+	for i, elem := range elems {
+		if elem.Error != nil {
+			results[i].Error = elem.Error.Error()
+			continue
+		}
+
+		if h, ok := elem.Result.(superwatcher.BlockHeader); ok {
+			results[i].Header = newHeader(h)
+			continue
+		}
+
+		b, err := json.Marshal(elem.Result)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal result of elems[%d]", i)
+		}
+
+		results[i].Result = b
+	}
+
+	return r.record("BatchCallContext", request, results, nil)
+}
+
+func (r *recorder) SubscribeNewHead(ctx context.Context, ch chan<- superwatcher.BlockHeader) (ethereum.Subscription, error) {
+	return r.client.SubscribeNewHead(ctx, ch)
+}
@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+	"github.com/soyart/superwatcher/internal/poller"
+	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/testlogs"
+)
+
+func init() {
+	testlogs.SetLogsPath("../../testlogs")
+}
+
+// pollAll gets the chain head, and then polls the test case range 3 times, seeing the chain reorg on the 2nd poll
+func pollAll(t *testing.T, tc *testlogs.TestConfig, client superwatcher.EthClient) []*superwatcher.PollerResult {
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal("unexpected BlockNumber error", err.Error())
+	}
+
+	p := poller.New(nil, nil, true, true, tc.ToBlock-tc.FromBlock+1, client, 1, superwatcher.PolicyExpensive)
+	p.SetDoParentHash(true)
+
+	var results []*superwatcher.PollerResult
+	for i := 0; i < 3; i++ {
+		result, err := p.Poll(context.Background(), tc.FromBlock, tc.ToBlock)
+		if err != nil {
+			t.Fatalf("unexpected error from poll %d: %s", i, err.Error())
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func TestRecordReplay(t *testing.T) {
+	tc := testlogs.TestCasesV1[0]
+	sim, err := reorgsim.NewReorgSimFromLogsFiles(tc.Param, tc.Events, tc.LogsFiles, "TestRecordReplay", 1)
+	if err != nil {
+		t.Fatal("cannot init ReorgSim", err.Error())
+	}
+
+	var recording bytes.Buffer
+	recorded := pollAll(t, tc, NewRecorder(sim, &recording))
+
+	replayer, err := NewReplayer(&recording)
+	if err != nil {
+		t.Fatal("cannot init replayer", err.Error())
+	}
+
+	replayed := pollAll(t, tc, replayer)
+	if len(replayed) != len(recorded) {
+		t.Fatalf("expecting %d replayed polls, got %d", len(recorded), len(replayed))
+	}
+
+	var reorged int
+	for i := range recorded {
+		expected, actual := recorded[i], replayed[i]
+		if len(actual.GoodBlocks) != len(expected.GoodBlocks) || len(actual.ReorgedBlocks) != len(expected.ReorgedBlocks) {
+			t.Fatalf("poll %d: expecting %d good and %d reorged blocks, got %d and %d", i,
+				len(expected.GoodBlocks), len(expected.ReorgedBlocks), len(actual.GoodBlocks), len(actual.ReorgedBlocks))
+		}
+
+		for j, b := range expected.GoodBlocks {
+			if actual.GoodBlocks[j].Hash != b.Hash || len(actual.GoodBlocks[j].Logs) != len(b.Logs) {
+				t.Fatalf("poll %d: unexpected good block %d", i, b.Number)
+			}
+		}
+
+		reorged += len(actual.ReorgedBlocks)
+	}
+
+	if reorged == 0 {
+		t.Fatal("expecting replayed reorged blocks")
+	}
+
+	// The recording is over
+	_, err = replayer.BlockNumber(context.Background())
+	if !errors.Is(err, ErrEndOfRecording) {
+		t.Fatalf("expecting ErrEndOfRecording, got %v", err)
+	}
+
+	_, err = replayer.HeaderByNumber(context.Background(), nil)
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("expecting ErrNotRecorded, got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+var (
+	ErrEndOfRecording = errors.New("end of recording")            // All recorded responses to a call were replayed
+	ErrNotRecorded    = errors.New("call not found in recording") // A call was never recorded, i.e. the replay diverged
+	ErrNoSubscription = errors.New("newHeads subscriptions are not replayed")
+)
+
+// replayer implements superwatcher.EthClient by serving recorded responses.
+type replayer struct {
+	sync.Mutex
+
+	queues    map[string][]*Record // Unreplayed records by Record.key, in Seq order
+	sentinels []error
+}
+
+// NewReplayer returns superwatcher.EthClient that replays Records read from |r|, e.g. written by NewRecorder.
+// Each call is answered with the earliest unreplayed record of the same method and arguments, so calls
+// get the same responses in the same order as when recorded, even if concurrent calls interleave differently.
+// Recorded errors are replayed as errors with the same messages. Errors whose messages contain any of |sentinels|
+// wrap that sentinel, so that callers can still check them with errors.Is, e.g. reorgsim.ErrExitBlockReached.
+func NewReplayer(r io.Reader, sentinels ...error) (superwatcher.EthClient, error) {
+	queues := make(map[string][]*Record)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		rec := new(Record)
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal record on line %d", line)
+		}
+
+		queues[rec.key()] = append(queues[rec.key()], rec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read records")
+	}
+
+	return &replayer{
+		queues:    queues,
+		sentinels: sentinels,
+	}, nil
+}
+
+// NewReplayerFromFile returns NewReplayer with records read from file |filename|.
+func NewReplayerFromFile(filename string, sentinels ...error) (superwatcher.EthClient, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open recording %s", filename)
+	}
+
+	defer f.Close()
+
+	return NewReplayer(f, sentinels...)
+}
+
+// next pops the next record of |method| with |request|, and unmarshals its response into |response|
+func (r *replayer) next(
+	method string,
+	request json.RawMessage,
+	response interface{},
+) error {
+	key := method + string(request)
+
+	r.Lock()
+	queue, ok := r.queues[key]
+	if !ok {
+		r.Unlock()
+		return errors.Wrapf(ErrNotRecorded, "%s %s", method, request)
+	}
+
+	if len(queue) == 0 {
+		r.Unlock()
+		return errors.Wrapf(ErrEndOfRecording, "%s %s", method, request)
+	}
+
+	rec := queue[0]
+	r.queues[key] = queue[1:]
+	r.Unlock()
+
+	if len(rec.Response) != 0 && response != nil {
+		if err := json.Unmarshal(rec.Response, response); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal %s response of record %d", method, rec.Seq)
+		}
+	}
+
+	if rec.Error != "" {
+		return r.recordedError(rec.Error)
+	}
+
+	return nil
+}
+
+// recordedError returns error with message |msg|, wrapping the first sentinel error found in |msg|
+func (r *replayer) recordedError(msg string) error {
+	for _, sentinel := range r.sentinels {
+		if strings.Contains(msg, sentinel.Error()) {
+			return errors.Wrap(sentinel, strings.TrimSuffix(strings.TrimSuffix(msg, sentinel.Error()), ": "))
+		}
+	}
+
+	return errors.New(msg)
+}
+
+func (r *replayer) BlockNumber(context.Context) (uint64, error) {
+	var n uint64
+	err := r.next("BlockNumber", nil, &n)
+	return n, err
+}
+
+func (r *replayer) SafeBlockNumber(context.Context) (uint64, error) {
+	var n uint64
+	err := r.next("SafeBlockNumber", nil, &n)
+	return n, err
+}
+
+func (r *replayer) FinalizedBlockNumber(context.Context) (uint64, error) {
+	var n uint64
+	err := r.next("FinalizedBlockNumber", nil, &n)
+	return n, err
+}
+
+func (r *replayer) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	request, err := filterLogsRequest(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []types.Log
+	if err := r.next("FilterLogs", request, &logs); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (r *replayer) HeaderByNumber(_ context.Context, number *big.Int) (superwatcher.BlockHeader, error) {
+	request, err := headerByNumberRequest(number)
+	if err != nil {
+		return nil, err
+	}
+
+	var h *header
+	if err := r.next("HeaderByNumber", request, &h); err != nil {
+		return nil, err
+	}
+
+	if h == nil {
+		return nil, nil
+	}
+
+	return h, nil
+}
+
+// BatchCallContext replays results of |elems|. Recorded headers overwrite elem.Result with a superwatcher.BlockHeader,
+// like reorgsim.ReorgSim does, while other results are unmarshaled into elem.Result.
+// Blocks with transactions from reorgsim.ReorgSim are recorded as headers only, so recordings of reorgsim.ReorgSim
+// cannot be replayed with PolicyExpensiveBlock.
+func (r *replayer) BatchCallContext(_ context.Context, elems []rpc.BatchElem) error {
+	request, err := batchCallRequest(elems)
+	if err != nil {
+		return err
+	}
+
+	var results []batchResult
+	if err := r.next("BatchCallContext", request, &results); err != nil {
+		return err
+	}
+
+	if len(results) != len(elems) {
+		return errors.Errorf("recorded %d batch results for %d elems", len(results), len(elems))
+	}
+
+	for i, result := range results {
+		switch {
+		case result.Error != "":
+			elems[i].Error = r.recordedError(result.Error)
+
+		case result.Header != nil:
+			elems[i].Result = result.Header
+
+		case elems[i].Result == nil:
+			raw := result.Result
+			elems[i].Result = &raw
+
+		default:
+			if reflect.TypeOf(elems[i].Result).Kind() != reflect.Pointer {
+				return errors.Errorf("elems[%d] has non-pointer result %T", i, elems[i].Result)
+			}
+
+			if err := json.Unmarshal(result.Result, elems[i].Result); err != nil {
+				return errors.Wrapf(err, "failed to unmarshal result of elems[%d]", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *replayer) SubscribeNewHead(context.Context, chan<- superwatcher.BlockHeader) (ethereum.Subscription, error) {
+	return nil, ErrNoSubscription
+}
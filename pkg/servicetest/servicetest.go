@@ -2,6 +2,7 @@ package servicetest
 
 import (
 	"context"
+	"io"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -10,6 +11,7 @@ import (
 	"github.com/soyart/superwatcher/pkg/components"
 	"github.com/soyart/superwatcher/pkg/components/mock"
 	"github.com/soyart/superwatcher/pkg/reorgsim"
+	"github.com/soyart/superwatcher/pkg/replay"
 )
 
 // TestCase will be converted into config.Config and reorgsim.Param to create TestComponents
@@ -76,6 +78,35 @@ func InitTestComponents(
 	}
 }
 
+// InitReplayTestComponents returns TestComponents whose client replays RPC calls recorded in |recordingFile|
+// with replay.NewRecorder, e.g. from a production incident or from TestComponents.Record.
+func InitReplayTestComponents(
+	conf *superwatcher.Config,
+	serviceEngine superwatcher.ServiceEngine,
+	recordingFile string,
+	firstRun bool, // If true, then the mock datagateway will return `ErrRecordNotFound` until `SetLastRecordedBlock`` is called
+) *TestComponents {
+	client, err := replay.NewReplayerFromFile(recordingFile, reorgsim.ErrExitBlockReached)
+	if err != nil {
+		panic("failed to create replay client: " + err.Error())
+	}
+
+	fakeRedis := mock.NewDataGatewayMem(conf.StartBlock, !firstRun)
+
+	return &TestComponents{
+		conf:           conf,
+		client:         client,
+		serviceEngine:  serviceEngine,
+		dataGatewayGet: fakeRedis,
+		dataGatewaySet: fakeRedis,
+	}
+}
+
+// Record makes |tc| record all RPC calls to |w|, so that the run can be replayed with InitReplayTestComponents.
+func (tc *TestComponents) Record(w io.Writer) {
+	tc.client = replay.NewRecorder(tc.client, w)
+}
+
 // RunServiceTestComponents runs the entire service using |components| and |param|.
 // It does so by setting up superwatcher.Emitter and superwatcher.Engine
 // and pass these objects to RunService.
@@ -116,7 +147,7 @@ func RunService(
 		defer wg.Done()
 
 		if err := emitter.Loop(ctx); err != nil {
-			if errors.Is(err, reorgsim.ErrExitBlockReached) {
+			if exited(err) {
 				emitter.Shutdown()
 				cancel()
 
@@ -128,7 +159,7 @@ func RunService(
 	}()
 
 	if err := engine.Loop(ctx); err != nil {
-		if errors.Is(err, reorgsim.ErrExitBlockReached) {
+		if exited(err) {
 			return nil
 		}
 
@@ -139,3 +170,8 @@ func RunService(
 
 	return retErr
 }
+
+// exited returns whether |err| is from the test client reaching its end, i.e. the test is done
+func exited(err error) bool {
+	return errors.Is(err, reorgsim.ErrExitBlockReached) || errors.Is(err, replay.ErrEndOfRecording)
+}
@@ -0,0 +1,102 @@
+package trackerstore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+// fileStore is the default, file-backed implementation of superwatcher.TrackerStore.
+// The snapshot is saved as JSON, and is written to a temporary file first before
+// being synced to disk and renamed to |path|, so that a crash during a save does not corrupt the last snapshot.
+type fileStore struct {
+	sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) superwatcher.TrackerStore {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) SaveTrackedBlocks(_ context.Context, blocks []*superwatcher.TrackedBlock) error {
+	s.Lock()
+	defer s.Unlock()
+
+	b, err := json.Marshal(blocks)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal tracked blocks")
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp tracker file")
+	}
+
+	tmp := f.Name()
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return errors.Wrapf(err, "failed to write temp tracker file %s", tmp)
+	}
+
+	// Sync before renaming, otherwise a crash may leave |path| pointing to an empty or partial file
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return errors.Wrapf(err, "failed to sync temp tracker file %s", tmp)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, "failed to close temp tracker file %s", tmp)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, "failed to rename temp tracker file to %s", s.path)
+	}
+
+	// Sync the directory so that the rename itself survives a crash
+	if err := syncDir(filepath.Dir(s.path)); err != nil {
+		return errors.Wrapf(err, "failed to sync tracker file directory of %s", s.path)
+	}
+
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *fileStore) LoadTrackedBlocks(context.Context) ([]*superwatcher.TrackedBlock, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, superwatcher.WrapErrRecordNotFound(err, s.path)
+		}
+
+		return nil, errors.Wrapf(err, "failed to read tracker file %s", s.path)
+	}
+
+	var blocks []*superwatcher.TrackedBlock
+	if err := json.Unmarshal(b, &blocks); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal tracker file %s", s.path)
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,67 @@
+package trackerstore
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/soyart/superwatcher"
+)
+
+func TestFileStore(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "tracker.json"))
+
+	_, err := store.LoadTrackedBlocks(context.Background())
+	if !errors.Is(err, superwatcher.ErrRecordNotFound) {
+		t.Fatalf("expecting ErrRecordNotFound from empty store, got %v", err)
+	}
+
+	b := &superwatcher.Block{
+		Number: 69,
+		Hash:   common.BigToHash(big.NewInt(69)),
+		Logs: []*types.Log{
+			{
+				Address: common.BigToAddress(big.NewInt(1)),
+				Topics:  []common.Hash{common.BigToHash(big.NewInt(2))},
+				TxHash:  common.BigToHash(big.NewInt(3)),
+				TxIndex: 4,
+				Index:   5,
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := store.SaveTrackedBlocks(context.Background(), []*superwatcher.TrackedBlock{superwatcher.NewTrackedBlock(b)}); err != nil {
+			t.Fatal("unexpected save error", err.Error())
+		}
+	}
+
+	trackedBlocks, err := store.LoadTrackedBlocks(context.Background())
+	if err != nil {
+		t.Fatal("unexpected load error", err.Error())
+	}
+
+	if len(trackedBlocks) != 1 {
+		t.Fatalf("expecting 1 tracked block, got %d", len(trackedBlocks))
+	}
+
+	loaded := trackedBlocks[0].Block()
+	if loaded.Number != b.Number || loaded.Hash != b.Hash || len(loaded.Logs) != len(b.Logs) {
+		t.Fatalf("unexpected loaded block %+v", loaded)
+	}
+
+	log, expected := loaded.Logs[0], b.Logs[0]
+	if log.Address != expected.Address || log.Topics[0] != expected.Topics[0] || log.TxHash != expected.TxHash ||
+		log.TxIndex != expected.TxIndex || log.Index != expected.Index {
+		t.Fatalf("unexpected loaded log %+v", log)
+	}
+
+	if log.BlockNumber != b.Number || log.BlockHash != b.Hash {
+		t.Fatal("loaded log has wrong block number or hash")
+	}
+}
@@ -7,6 +7,57 @@ type PollerResult struct {
 	LastGoodBlock uint64   // This number should be saved to StateDataGateway with SetLastRecordedBlock for the emitter
 	GoodBlocks    []*Block // Can be either (1) fresh, new blocks, or (2) blocks whose hashes had not changed yet.
 	ReorgedBlocks []*Block // Blocks that poller marked as removed. A service should undo/revert its actions done on the blocks.
+
+	SafeBlock      uint64 // The `safe` block number during the poll, only set if the poller checks finality
+	FinalizedBlock uint64 // The `finalized` block number during the poll, only set if the poller checks finality
+	ConfirmedBlock uint64 // Blocks after ConfirmedBlock do not have enough confirmations (Config.Confirmations), 0 means no confirmations
+
+	Cost *Cost // RPC cost of the poll, only set if the poller's EthClient implements CostReporter
+
+	// Rewind is true if ReorgedBlocks were not reorged, but rewound by Emitter.SeekTo or Emitter.Rewind.
+	// The blocks are emitted again as GoodBlocks in later results.
+	Rewind bool
+}
+
+// ConfirmedResult returns |result| with only blocks at or before result.ConfirmedBlock, i.e. blocks with
+// enough confirmations. Reorged blocks after ConfirmedBlock were never delivered, so they are dropped too.
+// If result.ConfirmedBlock is 0, or all blocks are confirmed, |result| is returned as is.
+func ConfirmedResult(
+	result *PollerResult,
+) *PollerResult {
+	if result.ConfirmedBlock == 0 || result.ConfirmedBlock >= result.ToBlock {
+		return result
+	}
+
+	confirmed := &PollerResult{
+		FromBlock:      result.FromBlock,
+		ToBlock:        result.ConfirmedBlock,
+		SafeBlock:      result.SafeBlock,
+		FinalizedBlock: result.FinalizedBlock,
+		ConfirmedBlock: result.ConfirmedBlock,
+		Cost:           result.Cost,
+		Rewind:         result.Rewind,
+	}
+
+	for _, b := range result.GoodBlocks {
+		if b.Number <= result.ConfirmedBlock {
+			confirmed.GoodBlocks = append(confirmed.GoodBlocks, b)
+		}
+	}
+
+	for _, b := range result.ReorgedBlocks {
+		if b.Number <= result.ConfirmedBlock {
+			confirmed.ReorgedBlocks = append(confirmed.ReorgedBlocks, b)
+		}
+	}
+
+	// Never go further than the poller did
+	confirmed.LastGoodBlock = LastGoodBlock(confirmed)
+	if result.LastGoodBlock < confirmed.LastGoodBlock {
+		confirmed.LastGoodBlock = result.LastGoodBlock
+	}
+
+	return confirmed
 }
 
 // LastGoodBlock computes `PollerResult.LastGoodBlock` based on |result|.
@@ -74,3 +74,46 @@ func TestLastGoodBlock(t *testing.T) {
 		}
 	}
 }
+
+func TestConfirmedResult(t *testing.T) {
+	result := &PollerResult{
+		FromBlock: 101,
+		ToBlock:   120,
+		GoodBlocks: []*Block{
+			{Number: 102},
+			{Number: 108},
+			{Number: 115},
+		},
+		ReorgedBlocks: []*Block{
+			{Number: 108},
+			{Number: 115},
+		},
+		LastGoodBlock: 120,
+	}
+
+	// No confirmations
+	if confirmed := ConfirmedResult(result); confirmed != result {
+		t.Fatal("expecting the same result without ConfirmedBlock")
+	}
+
+	result.ConfirmedBlock = 110
+	confirmed := ConfirmedResult(result)
+	if confirmed.ToBlock != 110 {
+		t.Fatalf("expecting toBlock 110, got %d", confirmed.ToBlock)
+	}
+	if len(confirmed.GoodBlocks) != 2 || len(confirmed.ReorgedBlocks) != 1 {
+		t.Fatalf(
+			"unexpected confirmed blocks: %d good blocks and %d reorged blocks",
+			len(confirmed.GoodBlocks), len(confirmed.ReorgedBlocks),
+		)
+	}
+	if confirmed.LastGoodBlock != 108 {
+		t.Fatalf("expecting lastGoodBlock 108, got %d", confirmed.LastGoodBlock)
+	}
+
+	// LastGoodBlock must not go further than the poller's
+	result.LastGoodBlock = 104
+	if confirmed := ConfirmedResult(result); confirmed.LastGoodBlock != 104 {
+		t.Fatalf("expecting lastGoodBlock 104, got %d", confirmed.LastGoodBlock)
+	}
+}
@@ -0,0 +1,8 @@
+package superwatcher
+
+import "github.com/ethereum/go-ethereum/core/types"
+
+// LogPredicate reports whether EmitterPoller should keep |log|, for conditions FilterQuery cannot express,
+// e.g. non-indexed log data such as a Transfer amount. A LogPredicate must be deterministic: it must always
+// return the same result for the same log, since the poller relies on that to detect chain reorgs.
+type LogPredicate func(log *types.Log) bool
@@ -0,0 +1,141 @@
+package superwatcher
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy configures retries for transient RPC failures. EmitterPoller uses it to retry each failed RPC call,
+// and Emitter uses it as a circuit breaker, so that a flaky node does not send errors to
+// ServiceEngine.HandleEmitterError until MaxFailures consecutive emitter loops have failed.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts for each RPC call, including the first one
+	MaxAttempts int `mapstructure:"max_attempts" yaml:"max_attempts" json:"maxAttempts"`
+
+	// InitialBackoff is the backoff before the first retry
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" yaml:"initial_backoff" json:"initialBackoff"`
+
+	// MaxBackoff caps the backoff, 0 means no cap
+	MaxBackoff time.Duration `mapstructure:"max_backoff" yaml:"max_backoff" json:"maxBackoff"`
+
+	// Multiplier grows the backoff after each retry, 0 means 2
+	Multiplier float64 `mapstructure:"multiplier" yaml:"multiplier" json:"multiplier"`
+
+	// Jitter randomizes each backoff by up to ±Jitter (0-1) of its value
+	Jitter float64 `mapstructure:"jitter" yaml:"jitter" json:"jitter"`
+
+	// MaxFailures is the number of consecutive failed emitter loops before the emitter circuit breaker opens,
+	// and the error is sent to ServiceEngine.HandleEmitterError, 0 means 1
+	MaxFailures int `mapstructure:"max_failures" yaml:"max_failures" json:"maxFailures"`
+
+	// Retryable classifies errors as transient (retryable), nil means IsTransientError
+	Retryable func(error) bool `mapstructure:"-" yaml:"-" json:"-"`
+}
+
+// DefaultRetryPolicy returns a RetryPolicy suitable for most hosted node providers.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		MaxFailures:    5,
+	}
+}
+
+// nonTransientErrors are errors that will not go away with retries
+var nonTransientErrors = []error{
+	context.Canceled,
+	ErrChainIsReorging,
+	ErrFinalizedBlockChanged,
+	ErrSuperwatcherBug,
+	ErrUserError,
+}
+
+// nonTransientRPCCodes are JSON-RPC error codes for requests that will never succeed
+var nonTransientRPCCodes = map[int]bool{
+	-32700: true, // Parse error
+	-32600: true, // Invalid request
+	-32601: true, // Method not found
+	-32602: true, // Invalid params
+}
+
+// IsTransientError is the default RetryPolicy classifier. Context errors, JSON-RPC errors for invalid requests,
+// and superwatcher errors that are not caused by the node are not transient. Other errors are.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, nonTransient := range nonTransientErrors {
+		if errors.Is(err, nonTransient) {
+			return false
+		}
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && nonTransientRPCCodes[rpcErr.ErrorCode()] {
+		return false
+	}
+
+	return true
+}
+
+// IsRetryable returns whether |err| should be retried with |p|.
+func (p *RetryPolicy) IsRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return IsTransientError(err)
+}
+
+// Backoff returns the backoff before retry number |retry| (starting from 1), with jitter.
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff != 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(backoff)
+}
+
+// Do calls |f| until it succeeds, returns a non-retryable error, or p.MaxAttempts is reached,
+// sleeping with p.Backoff between attempts. If |p| is nil, |f| is only called once.
+func (p *RetryPolicy) Do(ctx context.Context, f func() error) error {
+	err := f()
+	if p == nil {
+		return err
+	}
+
+	for retry := 1; retry < p.MaxAttempts && err != nil && p.IsRetryable(err); retry++ {
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "retry canceled after error: %s", err.Error())
+
+		case <-time.After(p.Backoff(retry)):
+			err = f()
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,78 @@
+package superwatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+
+	tests := map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		5: time.Second, // Capped
+	}
+
+	for retry, expected := range tests {
+		backoff := policy.Backoff(retry)
+		min, max := time.Duration(float64(expected)*0.8), time.Duration(float64(expected)*1.2)
+		if backoff < min || backoff > max {
+			t.Errorf("retry %d: expecting backoff within %s and %s, got %s", retry, min, max, backoff)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	var calls int
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	if err == nil || calls != 3 {
+		t.Fatalf("expecting error after 3 calls, got %d calls and error %v", calls, err)
+	}
+
+	// Non-transient errors are not retried
+	calls = 0
+	err = policy.Do(context.Background(), func() error {
+		calls++
+		return errors.Wrap(ErrUserError, "bad input")
+	})
+	if !errors.Is(err, ErrUserError) || calls != 1 {
+		t.Fatalf("expecting ErrUserError after 1 call, got %d calls and error %v", calls, err)
+	}
+
+	// Custom classifier
+	policy.Retryable = func(error) bool { return false }
+	calls = 0
+	_ = policy.Do(context.Background(), func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	if calls != 1 {
+		t.Fatalf("expecting 1 call with custom classifier, got %d", calls)
+	}
+
+	// Nil policy calls f once
+	var nilPolicy *RetryPolicy
+	calls = 0
+	_ = nilPolicy.Do(context.Background(), func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	if calls != 1 {
+		t.Fatalf("expecting 1 call with nil policy, got %d", calls)
+	}
+}
@@ -0,0 +1,17 @@
+package superwatcher
+
+import "github.com/ethereum/go-ethereum/common"
+
+// DefaultSubscriptionID is the subscription ID of logs matched by EmitterPoller's own
+// Addresses and Topics, when there are other subscriptions registered with Controller.AddSubscription.
+const DefaultSubscriptionID = "default"
+
+// Subscription is a named filter query. Unlike EmitterPoller's Addresses and Topics,
+// where every address is matched against every topic set, each subscription is queried separately,
+// so that watching unrelated contracts and events together does not over-fetch logs.
+// Logs in PollerResult are tagged with the IDs of subscriptions they matched, see Block.SubscriptionsOf.
+type Subscription struct {
+	ID        string           `json:"id"`
+	Addresses []common.Address `json:"addresses"`
+	Topics    [][]common.Hash  `json:"topics"`
+}
@@ -12,6 +12,11 @@ type SuperWatcher interface {
 	Emitter() Emitter
 	Engine() Engine
 	Shutdown()
+	// Pause, Resume, SeekTo and Rewind control the Emitter, see Emitter
+	Pause()
+	Resume()
+	SeekTo(uint64) error
+	Rewind(n uint64)
 
 	Controller
 }
@@ -26,6 +31,23 @@ type Controller interface {
 	SetDoHeader(bool)
 	// DoHeader returns if the EmitterPoller will fetch block headers for blocks with interesting logs
 	DoHeader() bool
+	// SetDoParentHash makes the EmitterPoller check parent hashes of all blocks in range to find chain reorgs
+	SetDoParentHash(bool)
+	// DoParentHash returns if the EmitterPoller is currently checking parent hashes of all blocks in range
+	DoParentHash() bool
+	// SetDoReceipts makes the EmitterPoller fetch transaction receipts for blocks with interesting logs
+	SetDoReceipts(bool)
+	// DoReceipts returns if the EmitterPoller will fetch transaction receipts for blocks with interesting logs
+	DoReceipts() bool
+	// SetDoFinality makes the EmitterPoller get the `safe` and `finalized` blocks to mark blocks with their finality
+	SetDoFinality(bool)
+	// DoFinality returns if the EmitterPoller is currently checking block finality
+	DoFinality() bool
+	// SetDoVerifyBlockHash makes the EmitterPoller re-fetch logs with EIP-234 block hash queries
+	// for blocks whose logs are inconsistent with their headers, instead of re-polling the whole range
+	SetDoVerifyBlockHash(bool)
+	// DoVerifyBlockHash returns if the EmitterPoller is currently verifying logs with block hash queries
+	DoVerifyBlockHash() bool
 	// Addresses reads EmitterPoller's current event log addresses for filter query
 	Addresses() []common.Address
 	// Topics reads EmitterPoller's current event log topics for filter query
@@ -38,4 +60,14 @@ type Controller interface {
 	SetAddresses([]common.Address)
 	// SetTopics changes EmitterPoller's event log topics on-the-fly
 	SetTopics([][]common.Hash)
+	// Policy gets EmitterPoller's current Policy
+	Policy() Policy
+	// AddSubscription registers a named filter query, which is queried separately from other subscriptions
+	AddSubscription(Subscription) error
+	// RemoveSubscription removes the subscription with the given ID
+	RemoveSubscription(string) error
+	// Subscriptions returns all registered subscriptions
+	Subscriptions() []Subscription
+	// SetPolicy changes EmitterPoller's Policy on-the-fly, migrating its tracked blocks to the new Policy
+	SetPolicy(Policy) error
 }
@@ -0,0 +1,106 @@
+package superwatcher
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type (
+	// TrackerStore persists blocks tracked by EmitterPoller, so that after a restart, the poller can
+	// compare the reloaded blocks with the chain and report blocks that were reorged during the downtime.
+	// The default implementation is file-backed, see package pkg/trackerstore.
+	TrackerStore interface {
+		// SaveTrackedBlocks replaces the saved snapshot with |blocks|
+		SaveTrackedBlocks(context.Context, []*TrackedBlock) error
+		// LoadTrackedBlocks returns the last saved snapshot, or ErrRecordNotFound if nothing was saved
+		LoadTrackedBlocks(context.Context) ([]*TrackedBlock, error)
+	}
+
+	// TrackedBlock is a snapshot of a Block tracked by EmitterPoller.
+	// Only block identity and log identities are kept, so logs restored from
+	// a TrackedBlock have no Data, and blocks have no Header, Transactions, or Receipts.
+	TrackedBlock struct {
+		Number uint64       `json:"number"`
+		Hash   common.Hash  `json:"hash"`
+		Logs   []TrackedLog `json:"logs"`
+
+		// Finality is kept, so that a restarted poller can tell if a finalized block changed
+		Finality Finality `json:"finality,omitempty"`
+
+		// Policy is the poller policy the block was tracked with, so that a restarted poller
+		// with a different policy can migrate the block to its own policy
+		Policy Policy `json:"policy"`
+		// HeadersOnly is true if the block was tracked with PolicyHeaders, and its logs are unknown
+		HeadersOnly bool `json:"headersOnly,omitempty"`
+	}
+
+	// TrackedLog is the identity of a log in TrackedBlock
+	TrackedLog struct {
+		Address common.Address `json:"address"`
+		Topics  []common.Hash  `json:"topics"`
+		TxHash  common.Hash    `json:"transactionHash"`
+		TxIndex uint           `json:"transactionIndex"`
+		Index   uint           `json:"logIndex"`
+
+		// Subscriptions are IDs of subscriptions the log matched, see Block.LogSubscriptions
+		Subscriptions []string `json:"subscriptions,omitempty"`
+	}
+)
+
+// NewTrackedBlock returns a snapshot of |b| for TrackerStore
+func NewTrackedBlock(b *Block) *TrackedBlock {
+	logs := make([]TrackedLog, len(b.Logs))
+	for i, log := range b.Logs {
+		logs[i] = TrackedLog{
+			Address: log.Address,
+			Topics:  log.Topics,
+			TxHash:  log.TxHash,
+			TxIndex: log.TxIndex,
+			Index:   log.Index,
+
+			Subscriptions: b.SubscriptionsOf(log),
+		}
+	}
+
+	return &TrackedBlock{
+		Number:   b.Number,
+		Hash:     b.Hash,
+		Logs:     logs,
+		Finality: b.Finality,
+	}
+}
+
+// Block restores the snapshot into *Block
+func (t *TrackedBlock) Block() *Block {
+	var logSubscriptions map[uint][]string
+	logs := make([]*types.Log, len(t.Logs))
+	for i, log := range t.Logs {
+		if log.Subscriptions != nil {
+			if logSubscriptions == nil {
+				logSubscriptions = make(map[uint][]string)
+			}
+
+			logSubscriptions[log.Index] = log.Subscriptions
+		}
+
+		logs[i] = &types.Log{
+			Address:     log.Address,
+			Topics:      log.Topics,
+			BlockNumber: t.Number,
+			BlockHash:   t.Hash,
+			TxHash:      log.TxHash,
+			TxIndex:     log.TxIndex,
+			Index:       log.Index,
+		}
+	}
+
+	return &Block{
+		Number:           t.Number,
+		Hash:             t.Hash,
+		Logs:             logs,
+		LogSubscriptions: logSubscriptions,
+		Finality:         t.Finality,
+	}
+}